@@ -0,0 +1,395 @@
+// Package aiger reads and-inverter graphs in the AIGER format and encodes them
+// into CNF.
+package aiger
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Lit is an AIGER literal: twice the variable, plus one when negated. The
+// literals 0 and 1 are the constants false and true.
+type Lit uint32
+
+const (
+	False = Lit(0)
+	True  = Lit(1)
+)
+
+// NewLit returns the literal of variable v, negated when neg is set.
+func NewLit(v int, neg bool) Lit {
+	if neg {
+		return Lit(2*v + 1)
+	}
+	return Lit(2 * v)
+}
+
+// Var returns the literal's variable.
+func (l Lit) Var() int {
+	return int(l >> 1)
+}
+
+// Sign returns true if the literal is negated.
+func (l Lit) Sign() bool {
+	return l&1 == 1
+}
+
+// Not negates a literal.
+func (l Lit) Not() Lit {
+	return l ^ 1
+}
+
+// Latch is a state bit, which is Init initially and Next in the following
+// step. An uninitialized latch has itself as Init.
+type Latch struct {
+	Lit  Lit
+	Next Lit
+	Init Lit
+}
+
+// And is an and gate defining Lhs as the conjunction of Rhs0 and Rhs1.
+type And struct {
+	Lhs  Lit
+	Rhs0 Lit
+	Rhs1 Lit
+}
+
+// AIG is an and-inverter graph.
+type AIG struct {
+	// MaxVar is the highest variable index.
+	MaxVar  int
+	Inputs  []Lit
+	Latches []Latch
+	Outputs []Lit
+	// Bad are the bad state properties and Constraints the invariant
+	// constraints of AIGER 1.9.
+	Bad         []Lit
+	Constraints []Lit
+	Ands        []And
+	// Symbols maps symbol table entries such as "i0" or "o1" to their names.
+	Symbols  map[string]string
+	Comments []string
+
+	// ands indexes and gates by their variable.
+	ands []int
+}
+
+// And returns the and gate defining variable v, or false when v isn't
+// defined by one.
+func (g *AIG) And(v int) (And, bool) {
+	if v >= len(g.ands) || g.ands[v] < 0 {
+		return And{}, false
+	}
+	return g.Ands[g.ands[v]], true
+}
+
+// Read reads an AIG in the ASCII (aag) or binary (aig) AIGER format.
+func Read(in io.Reader) (*AIG, error) {
+	r := &reader{r: bufio.NewReader(in)}
+	g := &AIG{Symbols: map[string]string{}}
+
+	header, err := r.fields()
+	if err != nil {
+		return nil, err
+	}
+	if len(header) < 6 || len(header) > 10 ||
+		(header[0] != "aag" && header[0] != "aig") {
+		return nil, r.errorf("invalid header")
+	}
+	binary := header[0] == "aig"
+	counts := make([]int, 9)
+
+	for i, field := range header[1:] {
+		if counts[i], err = strconv.Atoi(field); err != nil || counts[i] < 0 {
+			return nil, r.errorf("invalid header count %q", field)
+		}
+	}
+	m, nInputs, nLatches, nOutputs, nAnds := counts[0], counts[1], counts[2], counts[3], counts[4]
+	nBad, nConstraints := counts[5], counts[6]
+
+	if counts[7] > 0 || counts[8] > 0 {
+		return nil, r.errorf("justice and fairness properties aren't supported")
+	}
+	if m < nInputs+nLatches+nAnds {
+		return nil, r.errorf("maximum variable %d too small", m)
+	}
+	g.MaxVar = m
+
+	for i := 0; i < nInputs; i++ {
+		if binary {
+			g.Inputs = append(g.Inputs, NewLit(i+1, false))
+			continue
+		}
+		l, err := r.lits(g, 1, 1)
+		if err != nil {
+			return nil, err
+		}
+		g.Inputs = append(g.Inputs, l[0])
+	}
+	for i := 0; i < nLatches; i++ {
+		min := 2
+		if binary {
+			min = 1
+		}
+		lits, err := r.lits(g, min, min+1)
+		if err != nil {
+			return nil, err
+		}
+		if binary {
+			lits = append([]Lit{NewLit(nInputs+i+1, false)}, lits...)
+		}
+		latch := Latch{Lit: lits[0], Next: lits[1], Init: False}
+		if len(lits) == 3 {
+			latch.Init = lits[2]
+		}
+		if latch.Init != False && latch.Init != True && latch.Init != latch.Lit {
+			return nil, r.errorf("invalid latch initialization %d", latch.Init)
+		}
+		g.Latches = append(g.Latches, latch)
+	}
+	if g.Outputs, err = r.litLines(g, nOutputs); err != nil {
+		return nil, err
+	}
+	if g.Bad, err = r.litLines(g, nBad); err != nil {
+		return nil, err
+	}
+	if g.Constraints, err = r.litLines(g, nConstraints); err != nil {
+		return nil, err
+	}
+	for i := 0; i < nAnds; i++ {
+		var and And
+
+		if binary {
+			and.Lhs = NewLit(nInputs+nLatches+i+1, false)
+			d0, err := r.delta()
+			if err != nil {
+				return nil, err
+			}
+			d1, err := r.delta()
+			if err != nil {
+				return nil, err
+			}
+			if d0 > uint32(and.Lhs) || d1 > uint32(and.Lhs)-d0 {
+				return nil, fmt.Errorf("aiger: invalid delta in and gate %d", i)
+			}
+			and.Rhs0 = and.Lhs - Lit(d0)
+			and.Rhs1 = and.Rhs0 - Lit(d1)
+		} else {
+			lits, err := r.lits(g, 3, 3)
+			if err != nil {
+				return nil, err
+			}
+			and = And{lits[0], lits[1], lits[2]}
+		}
+		g.Ands = append(g.Ands, and)
+	}
+	if err := g.index(); err != nil {
+		return nil, err
+	}
+	if err := r.symbols(g); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// index validates the definitions of variables and indexes the and gates.
+func (g *AIG) index() error {
+	g.ands = make([]int, g.MaxVar+1)
+	defined := make([]bool, g.MaxVar+1)
+
+	for i := range g.ands {
+		g.ands[i] = -1
+	}
+	define := func(l Lit) error {
+		if l.Sign() || l.Var() == 0 || defined[l.Var()] {
+			return fmt.Errorf("aiger: invalid definition of literal %d", l)
+		}
+		defined[l.Var()] = true
+		return nil
+	}
+	for _, l := range g.Inputs {
+		if err := define(l); err != nil {
+			return err
+		}
+	}
+	for _, l := range g.Latches {
+		if err := define(l.Lit); err != nil {
+			return err
+		}
+	}
+	for i, and := range g.Ands {
+		if err := define(and.Lhs); err != nil {
+			return err
+		}
+		g.ands[and.Lhs.Var()] = i
+	}
+	return g.checkAcyclic()
+}
+
+// checkAcyclic returns an error when an and gate depends on itself.
+func (g *AIG) checkAcyclic() error {
+	// 0 is unvisited, 1 in progress and 2 done.
+	state := make([]uint8, g.MaxVar+1)
+	stack := []int{}
+
+	for _, and := range g.Ands {
+		stack = append(stack[:0], and.Lhs.Var())
+
+		for len(stack) > 0 {
+			v := stack[len(stack)-1]
+			a, ok := g.And(v)
+
+			if !ok || state[v] == 2 {
+				stack = stack[:len(stack)-1]
+				continue
+			}
+			if state[v] == 0 {
+				state[v] = 1
+
+				for _, l := range []Lit{a.Rhs0, a.Rhs1} {
+					switch state[l.Var()] {
+					case 0:
+						stack = append(stack, l.Var())
+					case 1:
+						if _, ok := g.And(l.Var()); ok {
+							return fmt.Errorf("aiger: cyclic definition of literal %d", a.Lhs)
+						}
+					}
+				}
+				continue
+			}
+			state[v] = 2
+			stack = stack[:len(stack)-1]
+		}
+	}
+	return nil
+}
+
+// reader reads the sections of an AIGER file, counting lines.
+type reader struct {
+	r    *bufio.Reader
+	line int
+}
+
+// errorf returns an error at the current line.
+func (r *reader) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("aiger: line %d: %s", r.line, fmt.Sprintf(format, args...))
+}
+
+// fields reads a line split into fields.
+func (r *reader) fields() ([]string, error) {
+	line, err := r.r.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	r.line++
+
+	return strings.Fields(line), nil
+}
+
+// lits reads a line of between min and max literals, each within the AIG's
+// maximum variable.
+func (r *reader) lits(g *AIG, min int, max int) ([]Lit, error) {
+	fields, err := r.fields()
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) < min || len(fields) > max {
+		return nil, r.errorf("expected %d literals, got %d", min, len(fields))
+	}
+	lits := []Lit{}
+
+	for _, field := range fields {
+		n, err := strconv.ParseUint(field, 10, 32)
+		if err != nil || int(n>>1) > g.MaxVar {
+			return nil, r.errorf("invalid literal %q", field)
+		}
+		lits = append(lits, Lit(n))
+	}
+	return lits, nil
+}
+
+// litLines reads n lines of a single literal.
+func (r *reader) litLines(g *AIG, n int) ([]Lit, error) {
+	lits := []Lit{}
+
+	for i := 0; i < n; i++ {
+		l, err := r.lits(g, 1, 1)
+		if err != nil {
+			return nil, err
+		}
+		lits = append(lits, l[0])
+	}
+	return lits, nil
+}
+
+// delta reads a variable length encoded delta of a binary and gate.
+func (r *reader) delta() (uint32, error) {
+	x := uint32(0)
+
+	for shift := 0; ; shift += 7 {
+		b, err := r.r.ReadByte()
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return 0, err
+		}
+		if shift > 28 {
+			return 0, fmt.Errorf("aiger: delta overflow")
+		}
+		x |= uint32(b&0x7f) << shift
+
+		if b&0x80 == 0 {
+			return x, nil
+		}
+	}
+}
+
+// symbols reads the optional symbol table and comment section.
+func (r *reader) symbols(g *AIG) error {
+	for {
+		line, err := r.r.ReadString('\n')
+		if line == "" && err == io.EOF {
+			return nil
+		}
+		if err != nil && err != io.EOF {
+			return err
+		}
+		r.line++
+		line = strings.TrimRight(line, "\r\n")
+
+		if line == "c" {
+			break
+		}
+		entry, name, ok := strings.Cut(line, " ")
+		if !ok || len(entry) < 2 || !strings.ContainsRune("ilobc", rune(entry[0])) {
+			return r.errorf("invalid symbol %q", line)
+		}
+		if _, err := strconv.Atoi(entry[1:]); err != nil {
+			return r.errorf("invalid symbol %q", line)
+		}
+		g.Symbols[entry] = name
+	}
+	for {
+		line, err := r.r.ReadString('\n')
+		if line != "" {
+			g.Comments = append(g.Comments, strings.TrimRight(line, "\r\n"))
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package aiger
+
+import (
+	"github.com/ericr/saturday/config"
+	"github.com/ericr/saturday/solver"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// satisfiable returns whether the AIG's encoding is satisfiable.
+func satisfiable(g *AIG) bool {
+	s := solver.New(config.New())
+
+	for _, clause := range g.Encode() {
+		s.AddClause(clause)
+	}
+	return s.Solve([]int{})
+}
+
+func TestRead(t *testing.T) {
+	want := &AIG{
+		MaxVar:  3,
+		Inputs:  []Lit{2, 4},
+		Outputs: []Lit{6},
+		Ands:    []And{{6, 4, 2}},
+	}
+
+	for _, input := range []string{
+		"aag 3 2 0 1 1\n2\n4\n6\n6 4 2\ni0 x\nc\nand\n",
+		"aig 3 2 0 1 1\n6\n\x02\x02i0 x\nc\nand\n",
+	} {
+		g, err := Read(strings.NewReader(input))
+		if err != nil {
+			t.Fatalf("TestRead() failed, got: %v", err)
+		}
+		if g.MaxVar != want.MaxVar || !reflect.DeepEqual(g.Inputs, want.Inputs) ||
+			!reflect.DeepEqual(g.Outputs, want.Outputs) ||
+			!reflect.DeepEqual(g.Ands, want.Ands) {
+			t.Fatalf("TestRead() failed, got: %+v", g)
+		}
+		if g.Symbols["i0"] != "x" || !reflect.DeepEqual(g.Comments, []string{"and"}) {
+			t.Fatalf("TestRead() failed, got symbols: %v %v", g.Symbols, g.Comments)
+		}
+	}
+}
+
+func TestReadLatches(t *testing.T) {
+	g, err := Read(strings.NewReader("aag 3 1 2 0 0 1\n2\n4 5\n6 2 6\n4\n"))
+	if err != nil {
+		t.Fatalf("TestReadLatches() failed, got: %v", err)
+	}
+	want := []Latch{{4, 5, False}, {6, 2, 6}}
+
+	if !reflect.DeepEqual(g.Latches, want) || !reflect.DeepEqual(g.Bad, []Lit{4}) {
+		t.Fatalf("TestReadLatches() failed, got: %+v", g)
+	}
+}
+
+func TestReadInvalid(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"aag 1 1 0 0\n",
+		"aag 1 2 0 0 0\n2\n4\n",
+		"aag 2 1 0 1 1\n2\n4\n4 4 2\n",
+		"aag 3 1 0 1 2\n2\n4\n4 6 2\n6 4 2\n",
+		"aag 1 1 0 1 0\n2\n8\n",
+		"aag 2 1 1 0 0\n2\n4 2 1\n4 2\n",
+		"aag 1 1 0 0 0 0 0 1\n2\n",
+	} {
+		if _, err := Read(strings.NewReader(input)); err == nil {
+			t.Fatalf("TestReadInvalid() failed on %q", input)
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	for _, test := range []struct {
+		input string
+		want  bool
+	}{
+		// x & y
+		{"aag 3 2 0 1 1\n2\n4\n6\n6 4 2\n", true},
+		// x & !x
+		{"aag 2 1 0 1 1\n2\n4\n4 3 2\n", false},
+		// !(x & !x)
+		{"aag 2 1 0 1 1\n2\n5\n4 3 2\n", true},
+		// Constants.
+		{"aag 0 0 0 1 0\n1\n", true},
+		{"aag 0 0 0 1 0\n0\n", false},
+		// (x & y) | (!x & !y) with x != y as a constraint.
+		{"aag 7 2 0 1 4 0 1\n2\n4\n13\n10\n6 4 2\n8 5 3\n10 7 9\n12 7 9\n", false},
+		// A bad state property over a latch.
+		{"aag 1 0 1 0 0 1\n2 3\n2\n", true},
+	} {
+		g, err := Read(strings.NewReader(test.input))
+		if err != nil {
+			t.Fatalf("TestEncode() failed, got: %v", err)
+		}
+		if got := satisfiable(g); got != test.want {
+			t.Fatalf("TestEncode() failed on %q, got: %v", test.input, got)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package aiger
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/ericr/saturday/config"
+	"github.com/ericr/saturday/solver"
+	"io"
+)
+
+// BMC checks the safety properties of a sequential circuit by bounded model
+// checking. The circuit is unrolled one step at a time into a single solver,
+// so that the clauses learnt at a bound are kept for the next ones.
+type BMC struct {
+	aig *AIG
+	sat *solver.Solver
+	// props are the safety properties, which are violated when true: the bad
+	// state properties, or the outputs when there are none.
+	props []Lit
+	nVars int
+	// frames contains the encoder of each step.
+	frames []*Encoder
+	// latches contains the CNF variable of each latch in the initial state, and
+	// inputs the CNF variable of each input at each step.
+	latches []int
+	inputs  [][]int
+	// bad is the index of the property violated by the counterexample, or -1.
+	bad int
+}
+
+// NewBMC returns a bounded model checker of the AIG.
+func NewBMC(g *AIG, c *config.Config) *BMC {
+	b := &BMC{aig: g, sat: solver.New(c), props: g.Bad, bad: -1}
+
+	if len(b.props) == 0 {
+		b.props = g.Outputs
+	}
+	for _, l := range g.Latches {
+		v := b.newVar()
+		b.latches = append(b.latches, v)
+
+		switch l.Init {
+		case False:
+			b.sat.AddClause([]int{-v})
+		case True:
+			b.sat.AddClause([]int{v})
+		}
+	}
+	return b
+}
+
+// Step unrolls the circuit one step further and returns whether a property
+// can be violated at it. Properties are checked in the initial state first.
+func (b *BMC) Step() bool {
+	k := len(b.frames)
+	inputs := map[int]int{}
+	latches := map[int]int{}
+
+	b.inputs = append(b.inputs, []int{})
+	for _, l := range b.aig.Inputs {
+		inputs[l.Var()] = b.newVar()
+		b.inputs[k] = append(b.inputs[k], inputs[l.Var()])
+	}
+	for i, l := range b.aig.Latches {
+		latches[l.Lit.Var()] = i
+	}
+	e := NewEncoder(b.aig, b.newVar, func(v int, positive bool) int {
+		if p, ok := inputs[v]; ok {
+			return p
+		}
+		i := latches[v]
+		if k == 0 {
+			return b.latches[i]
+		}
+		// A latch takes the value of its next state function at the previous
+		// step, needed in the same polarity.
+		return b.frames[k-1].Lit(b.aig.Latches[i].Next, positive)
+	})
+	b.frames = append(b.frames, e)
+
+	for _, l := range b.aig.Constraints {
+		b.sat.AddClause([]int{e.Lit(l, true)})
+	}
+	// The properties at this step are only checked under an activation
+	// literal, which is dropped once they're known to hold.
+	act := b.newVar()
+	target := []int{-act}
+
+	for _, l := range b.props {
+		target = append(target, e.Lit(l, true))
+	}
+	for _, f := range b.frames {
+		for _, clause := range f.Clauses() {
+			b.sat.AddClause(clause)
+		}
+	}
+	b.sat.AddClause(target)
+
+	if !b.sat.Solve([]int{act}) {
+		b.sat.AddClause([]int{-act})
+		return false
+	}
+	model := b.model()
+
+	for i, p := range target[1:] {
+		if model[p] {
+			b.bad = i
+			break
+		}
+	}
+	return true
+}
+
+// Bound returns the number of steps unrolled.
+func (b *BMC) Bound() int {
+	return len(b.frames)
+}
+
+// Solver returns the underlying solver.
+func (b *BMC) Solver() *solver.Solver {
+	return b.sat
+}
+
+// WriteWitness writes the counterexample found by the last call to Step in
+// the AIGER witness format: the violated property, the initial state and the
+// inputs at each step.
+func (b *BMC) WriteWitness(out io.Writer) error {
+	if b.bad < 0 {
+		return fmt.Errorf("aiger: no counterexample")
+	}
+	w := bufio.NewWriter(out)
+	model := b.model()
+
+	fmt.Fprintf(w, "1\nb%d\n", b.bad)
+	writeBits(w, model, b.latches)
+
+	for _, vars := range b.inputs {
+		writeBits(w, model, vars)
+	}
+	fmt.Fprint(w, ".\n")
+
+	return w.Flush()
+}
+
+// model returns the truth value of each literal in the solver's model.
+// Variables that don't occur in the model are false.
+func (b *BMC) model() map[int]bool {
+	model := map[int]bool{}
+
+	for _, p := range b.sat.Answer() {
+		model[p] = true
+	}
+	return model
+}
+
+// newVar returns a new CNF variable.
+func (b *BMC) newVar() int {
+	b.nVars++
+	return b.nVars
+}
+
+// writeBits writes the values of vars in the model as a line of 0s and 1s.
+func writeBits(w *bufio.Writer, model map[int]bool, vars []int) {
+	for _, v := range vars {
+		if model[v] {
+			w.WriteByte('1')
+		} else {
+			w.WriteByte('0')
+		}
+	}
+	w.WriteByte('\n')
+}
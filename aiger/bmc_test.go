@@ -0,0 +1,97 @@
+package aiger
+
+import (
+	"bytes"
+	"github.com/ericr/saturday/config"
+	"strings"
+	"testing"
+)
+
+// value evaluates l given the values of inputs and latches.
+func value(g *AIG, values map[int]bool, l Lit) bool {
+	if and, ok := g.And(l.Var()); ok {
+		return (value(g, values, and.Rhs0) && value(g, values, and.Rhs1)) != l.Sign()
+	}
+	return (l.Var() != 0 && values[l.Var()]) != (l == True || l.Sign())
+}
+
+// replay simulates a witness and returns whether it violates its property at
+// the last step while meeting the constraints.
+func replay(g *AIG, props []Lit, witness string) bool {
+	lines := strings.Split(strings.TrimSuffix(witness, ".\n"), "\n")
+	lines = lines[:len(lines)-1]
+	if len(lines) < 4 || lines[0] != "1" || lines[1] != "b0" {
+		return false
+	}
+	values := map[int]bool{}
+	for i, l := range g.Latches {
+		values[l.Lit.Var()] = lines[2][i] == '1'
+	}
+	for k, line := range lines[3:] {
+		for i, l := range g.Inputs {
+			values[l.Var()] = line[i] == '1'
+		}
+		for _, l := range g.Constraints {
+			if !value(g, values, l) {
+				return false
+			}
+		}
+		if k == len(lines)-4 {
+			return value(g, values, props[0])
+		}
+		next := map[int]bool{}
+		for _, l := range g.Latches {
+			next[l.Lit.Var()] = value(g, values, l.Next)
+		}
+		values = next
+	}
+	return false
+}
+
+func TestBMC(t *testing.T) {
+	for _, test := range []struct {
+		input string
+		// want is the bound of the counterexample, or 0 when there's none.
+		want int
+	}{
+		// A two bit counter reaching 3.
+		{"aag 5 0 2 0 3 1\n2 3\n4 10\n6\n6 2 4\n8 3 5\n10 7 9\n", 4},
+		// A latch copying an input.
+		{"aag 2 1 1 0 0 1\n2\n4 2\n4\n", 2},
+		// An uninitialized latch.
+		{"aag 1 0 1 0 0 1\n2 2 2\n2\n", 1},
+		// The same, as an output.
+		{"aag 2 1 1 1 0\n2\n4 2\n4\n", 2},
+		// A latch stuck at 0.
+		{"aag 1 0 1 0 0 1\n2 2\n2\n", 0},
+		// A latch copying an input constrained to 0.
+		{"aag 2 1 1 0 0 1 1\n2\n4 2\n4\n3\n", 0},
+	} {
+		g, err := Read(strings.NewReader(test.input))
+		if err != nil {
+			t.Fatalf("TestBMC() failed, got: %v", err)
+		}
+		b := NewBMC(g, config.New())
+		got := 0
+
+		for b.Bound() < 8 {
+			if b.Step() {
+				got = b.Bound()
+				break
+			}
+		}
+		if got != test.want {
+			t.Fatalf("TestBMC() failed on %q, got: %v", test.input, got)
+		}
+		if got == 0 {
+			continue
+		}
+		var w bytes.Buffer
+		if err := b.WriteWitness(&w); err != nil {
+			t.Fatalf("TestBMC() failed, got: %v", err)
+		}
+		if !replay(g, b.props, w.String()) {
+			t.Fatalf("TestBMC() failed on %q, got witness: %q", test.input, w.String())
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package aiger
+
+// Polarities in which and gates are encoded.
+const (
+	polarityPos = 1 << iota
+	polarityNeg
+)
+
+// Encoder encodes the cones of AIG literals into CNF by Tseitin
+// transformation with polarity optimization, as described by Plaisted and
+// Greenbaum: an and gate is only constrained in the directions its literals
+// occur in the clauses using it.
+type Encoder struct {
+	aig *AIG
+	// newVar returns a new CNF variable.
+	newVar func() int
+	// leaf returns the CNF literal of an input or latch variable, which is
+	// needed in the given polarity.
+	leaf func(v int, positive bool) int
+	// lits contains the CNF literal of each AIG variable, 0 when unmapped.
+	lits []int
+	// encoded contains the polarities each variable was encoded in.
+	encoded  []uint8
+	constant int
+	clauses  [][]int
+}
+
+// NewEncoder returns an encoder of the AIG, numbering and gates with newVar
+// and mapping inputs and latches with leaf.
+func NewEncoder(g *AIG, newVar func() int, leaf func(v int, positive bool) int) *Encoder {
+	return &Encoder{
+		aig:     g,
+		newVar:  newVar,
+		leaf:    leaf,
+		lits:    make([]int, g.MaxVar+1),
+		encoded: make([]uint8, g.MaxVar+1),
+	}
+}
+
+// Lit returns the CNF literal of l, encoding its cone for occurrences in the
+// given polarity: when positive the literal implies l, otherwise l implies the
+// literal.
+func (e *Encoder) Lit(l Lit, positive bool) int {
+	v := l.Var()
+	polarity := positive != l.Sign()
+
+	if v == 0 {
+		if e.constant == 0 {
+			e.constant = e.newVar()
+			e.clauses = append(e.clauses, []int{e.constant})
+		}
+		if l == True {
+			return e.constant
+		}
+		return -e.constant
+	}
+	and, isAnd := e.aig.And(v)
+	flag := uint8(polarityNeg)
+	if polarity {
+		flag = polarityPos
+	}
+
+	if e.encoded[v]&flag == 0 {
+		e.encoded[v] |= flag
+
+		if !isAnd {
+			e.lits[v] = e.leaf(v, polarity)
+		} else {
+			if e.lits[v] == 0 {
+				e.lits[v] = e.newVar()
+			}
+			x := e.lits[v]
+
+			if polarity {
+				e.clauses = append(e.clauses,
+					[]int{-x, e.Lit(and.Rhs0, true)},
+					[]int{-x, e.Lit(and.Rhs1, true)})
+			} else {
+				e.clauses = append(e.clauses,
+					[]int{x, -e.Lit(and.Rhs0, false), -e.Lit(and.Rhs1, false)})
+			}
+		}
+	}
+	if l.Sign() {
+		return -e.lits[v]
+	}
+	return e.lits[v]
+}
+
+// Clauses returns the clauses encoded since the last call.
+func (e *Encoder) Clauses() [][]int {
+	clauses := e.clauses
+	e.clauses = nil
+
+	return clauses
+}
+
+// Encode returns CNF that is satisfiable iff an output of the combinational
+// circuit can be true, or a bad state property when there are no outputs,
+// while all invariant constraints hold. Latches are treated as inputs. Inputs
+// and latches are numbered as their AIG variables, and and gates after them.
+func (g *AIG) Encode() [][]int {
+	n := g.MaxVar
+	e := NewEncoder(g,
+		func() int {
+			n++
+			return n
+		},
+		func(v int, positive bool) int {
+			return v
+		})
+	roots := g.Outputs
+	if len(roots) == 0 {
+		roots = g.Bad
+	}
+	target := []int{}
+
+	for _, l := range roots {
+		target = append(target, e.Lit(l, true))
+	}
+	clauses := [][]int{target}
+
+	for _, l := range g.Constraints {
+		clauses = append(clauses, []int{e.Lit(l, true)})
+	}
+	return append(clauses, e.Clauses()...)
+}
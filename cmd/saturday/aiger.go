@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/ericr/saturday/aiger"
+	"github.com/ericr/saturday/config"
+	"github.com/ericr/saturday/solver"
+	"os"
+	"time"
+)
+
+// isAIGER returns whether the buffered input starts with an AIGER header.
+func isAIGER(in *bufio.Reader) bool {
+	magic, _ := in.Peek(4)
+	return string(magic) == "aag " || string(magic) == "aig "
+}
+
+// solveAIG checks whether an output of a combinational circuit can be true,
+// reporting the values of its inputs and latches when it can.
+func solveAIG(in *bufio.Reader, path string, conf *config.Config) {
+	g, err := aiger.Read(in)
+	if err != nil {
+		fmt.Printf("%s: %s\n", path, err)
+		os.Exit(1)
+	}
+	conf.Logger.Printf("Starting Saturday %s solver", solver.Version())
+
+	tStart := time.Now()
+	sat := solver.New(conf)
+
+	for _, clause := range g.Encode() {
+		sat.AddClause(clause)
+	}
+	ok := sat.Solve([]int{})
+
+	conf.Logger.Print("Finished solving")
+	stats := solverStats(sat, time.Now().Sub(tStart))
+
+	if !ok {
+		report(conf, statusUNSAT, nil, stats)
+	}
+	leaves := map[int]bool{}
+	for _, l := range g.Inputs {
+		leaves[l.Var()] = true
+	}
+	for _, l := range g.Latches {
+		leaves[l.Lit.Var()] = true
+	}
+	model := []int{}
+
+	for _, p := range sat.Answer() {
+		if leaves[p] || leaves[-p] {
+			model = append(model, p)
+		}
+	}
+	report(conf, statusSAT, [][]int{model}, stats)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/ericr/saturday/aiger"
+	"github.com/ericr/saturday/config"
+	"io"
+	"os"
+	"time"
+)
+
+// bmc runs the bmc command, which checks the safety properties of an AIGER
+// circuit up to a bound and writes a counterexample in the AIGER witness
+// format to stdout.
+func bmc(conf *config.Config, args []string) {
+	fs := flag.NewFlagSet("bmc", flag.ExitOnError)
+	bound := fs.Int("k", 20, "maximum number of steps to unroll")
+
+	solverFlags(fs, conf)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: saturday bmc [args] input.aag"+
+			"\n\nThe bad state properties are checked, or the outputs when there"+
+			" are none. The exit code is 10 when a property is violated and 0"+
+			" otherwise.\n\nValid Arguments:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() < 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	path := fs.Arg(0)
+	in, err := openInput(path)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	g, err := aiger.Read(in)
+	if err != nil {
+		fmt.Printf("%s: %s\n", path, err)
+		os.Exit(1)
+	}
+	tStart := time.Now()
+	b := aiger.NewBMC(g, conf)
+	st := statusUnknown
+
+	for b.Bound() <= *bound {
+		if b.Step() {
+			st = statusSAT
+			break
+		}
+		conf.Logger.Printf("No counterexample at step %d", b.Bound()-1)
+	}
+	stats := append(solverStats(b.Solver(), time.Now().Sub(tStart)),
+		stat{"Bound", b.Bound() - 1})
+	writeText(os.Stderr, io.Discard, st, nil, stats)
+
+	if st != statusSAT {
+		fmt.Print("2\n")
+		os.Exit(competitionExitCode(st))
+	}
+	if err := b.WriteWitness(os.Stdout); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	os.Exit(competitionExitCode(st))
+}
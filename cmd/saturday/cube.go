@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/ericr/saturday/config"
+	"github.com/ericr/saturday/encoding"
+	"github.com/ericr/saturday/solver"
+	"os"
+	"runtime"
+	"time"
+)
+
+// cube runs the cube command, which splits the input into cubes by lookahead
+// and either solves them in parallel or writes them out in iCNF format.
+func cube(conf *config.Config, args []string) {
+	fs := flag.NewFlagSet("cube", flag.ExitOnError)
+	depth := fs.Int("depth", 8, "maximum number of decisions per cube")
+	emit := fs.Bool("emit", false,
+		"write the cubes in iCNF format to stdout instead of solving them")
+
+	conf.Threads = runtime.NumCPU()
+	solverFlags(fs, conf)
+	ioFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: saturday cube [args] input.cnf"+
+			"\n\nValid Arguments:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() < 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	sentences, err := readCNF(fs.Arg(0), lenient)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	tStart := time.Now()
+	sat := solver.New(conf)
+
+	for _, clause := range sentences {
+		sat.AddClause(clause)
+	}
+	cubes := sat.Cube(*depth)
+	conf.Logger.Printf("Split into %d cubes", len(cubes))
+
+	if *emit {
+		if err := encoding.WriteICNF(os.Stdout, sentences, cubes); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+	ok, model := solver.Conquer(conf, sentences, cubes, conf.Threads)
+
+	conf.Logger.Print("Finished solving")
+	stats := timeStats(time.Now().Sub(tStart))
+
+	if !ok {
+		report(conf, statusUNSAT, nil, stats)
+	}
+	report(conf, statusSAT, [][]int{model}, stats)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"github.com/ericr/saturday/config"
+	"github.com/ericr/saturday/encoding"
+	"github.com/ericr/saturday/solver"
+	"io"
+	"os"
+	"time"
+)
+
+// incremental solves iCNF read from path, solving each query as soon as its
+// assumption line is read and reporting one result per query. Input without
+// queries is solved once at the end. It exits with the code of the last
+// result.
+func incremental(in io.Reader, path string, conf *config.Config) {
+	conf.Logger.Printf("Starting Saturday %s incremental solver", solver.Version())
+
+	p := encoding.NewICNFParser(in)
+	p.Lenient = lenient
+	sat := solver.New(conf)
+	queries := 0
+	st := statusUnknown
+
+	if trace {
+		sat.SetTracer(solver.NewTextTracer(os.Stderr))
+	}
+	for {
+		sentences, assumps, err := p.Next()
+		if err != nil && err != io.EOF {
+			fmt.Printf("%s:%s\n", path, err)
+			os.Exit(1)
+		}
+		for _, clause := range sentences {
+			sat.AddClause(clause)
+		}
+		if err == io.EOF && queries > 0 {
+			break
+		}
+		queries++
+		st = query(sat, assumps, conf)
+
+		if err == io.EOF {
+			break
+		}
+	}
+	conf.Logger.Printf("Finished solving %d queries", queries)
+	os.Exit(exitCode(st))
+}
+
+// query solves under assumps and writes the result, along with the failed
+// assumptions when it's unsatisfiable.
+func query(sat *solver.Solver, assumps []int, conf *config.Config) status {
+	tStart := time.Now()
+	st := statusUNSAT
+	models := [][]int{}
+	failed := []int(nil)
+
+	if sat.Solve(assumps) {
+		st = statusSAT
+		models = append(models, sat.Answer())
+	} else if sat.Status().Undef() {
+		st = statusUnknown
+	} else {
+		failed = sat.FailedAssumptions()
+	}
+	writeResult(conf, st, models, failed, solverStats(sat, time.Now().Sub(tStart)))
+
+	return st
+}
@@ -6,78 +6,144 @@ import (
 	"fmt"
 	"github.com/ericr/saturday/config"
 	"github.com/ericr/saturday/encoding"
+	"github.com/ericr/saturday/sls"
 	"github.com/ericr/saturday/solver"
+	"io"
 	"os"
 	"time"
 )
 
+// inputBufferSize is the size of the input buffer, within which the header
+// is looked for.
+const inputBufferSize = 1 << 16
+
+// Settings of the command line tool alone, which don't configure solvers.
+var (
+	// trace enables writing search events to standard error.
+	trace bool
+	// format is the output format.
+	format = formatText
+	// lenient enables lenient parsing of DIMACS input.
+	lenient bool
+)
+
 func main() {
 	conf := config.New()
+
+	if len(os.Args) > 1 && os.Args[1] == "cube" {
+		cube(conf, os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "bmc" {
+		bmc(conf, os.Args[2:])
+		return
+	}
 	parseFlags(conf)
 
-	sentences, err := readCNF(flag.Args()[0])
+	path := flag.Args()[0]
+	in, err := openInput(path)
 	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
-	sat := solver.New(conf)
-
-	for _, clause := range sentences {
-		sat.AddClause(clause)
+	if isAIGER(in) {
+		solveAIG(in, path, conf)
+	}
+	if encoding.DetectFormat(in) == encoding.FormatICNF {
+		incremental(in, path, conf)
+	}
+	prefix, sentences, err := parseCNF(in, path, lenient)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if len(prefix) > 0 {
+		solveQBF(prefix, sentences, conf)
+	}
+	if conf.LocalSearchOnly {
+		localSearch(sentences, conf)
 	}
 	conf.Logger.Printf("Starting Saturday %s solver", solver.Version())
 
 	tStart := time.Now()
-	models := solve(sat, conf)
+	sat, models := solve(sentences, conf)
 
 	conf.Logger.Print("Finished solving")
 
-	displayStats(sat, time.Now().Sub(tStart))
+	stats := solverStats(sat, time.Now().Sub(tStart))
 
+	if len(models) == 0 && sat.Status().Undef() {
+		report(conf, statusUnknown, nil, stats)
+	}
 	if len(models) == 0 {
-		fmt.Fprint(os.Stderr, "UNSAT\n")
-		os.Exit(3)
+		report(conf, statusUNSAT, nil, stats)
 	}
-	fmt.Fprint(os.Stderr, "SAT\n")
-	displayModels(models)
-	os.Exit(0)
+	report(conf, statusSAT, models, stats)
 }
 
-func solve(sat *solver.Solver, conf *config.Config) [][]int {
+func solve(sentences [][]int, conf *config.Config) (*solver.Solver, [][]int) {
+	if conf.Threads > 1 && conf.Models <= 1 {
+		return solvePortfolio(sentences, conf)
+	}
+	sat := solver.New(conf)
+
+	for _, clause := range sentences {
+		sat.AddClause(clause)
+	}
+	if trace {
+		sat.SetTracer(solver.NewTextTracer(os.Stderr))
+	}
 	if conf.Models > 1 {
-		return sat.SolveMany([]int{}, conf.Models)
+		return sat, sat.SolveMany([]int{}, conf.Models)
 	}
 	if sat.Solve([]int{}) {
-		return [][]int{sat.Answer()}
+		return sat, [][]int{sat.Answer()}
 	}
-	return [][]int{}
+	return sat, [][]int{}
 }
 
-func displayModels(models [][]int) {
-	for _, model := range models {
-		for _, p := range model {
-			fmt.Fprintf(os.Stdout, "%d ", p)
-		}
-		fmt.Fprint(os.Stdout, "0\n")
+func solvePortfolio(sentences [][]int, conf *config.Config) (*solver.Solver, [][]int) {
+	p := solver.NewPortfolio(conf, conf.Threads)
+
+	for _, clause := range sentences {
+		p.AddClause(clause)
 	}
+	if p.Solve([]int{}) {
+		return p.Winner(), [][]int{p.Answer()}
+	}
+	return p.Winner(), [][]int{}
 }
 
-func displayStats(s *solver.Solver, t time.Duration) {
-	fmt.Fprint(os.Stderr, "\n")
-	fmt.Fprintf(os.Stderr, "Time Taken   : %fs\n", t.Seconds())
-	fmt.Fprintf(os.Stderr, "Variables    : %d\n", s.NVars())
-	fmt.Fprintf(os.Stderr, "Constraints  : %d\n", s.NConstrs())
-	fmt.Fprintf(os.Stderr, "Conflicts    : %d\n", s.NConflicts())
-	fmt.Fprintf(os.Stderr, "Propagations : %d\n", s.NPropagations())
-	fmt.Fprintf(os.Stderr, "Restarts     : %d\n", s.NRestarts())
-	fmt.Fprintf(os.Stderr, "Decisions    : %d\n", s.NDecisions())
-	fmt.Fprint(os.Stderr, "\n")
+// localSearch solves with local search alone, reporting an unknown result when
+// the flip limit is reached.
+func localSearch(sentences [][]int, conf *config.Config) {
+	conf.Logger.Printf("Starting Saturday %s local search", solver.Version())
+
+	tStart := time.Now()
+	w := sls.New(sentences, conf)
+	ok := w.Solve(conf.MaxFlips)
+
+	conf.Logger.Print("Finished searching")
+
+	stats := append(timeStats(time.Now().Sub(tStart)),
+		stat{"Flips", w.NFlips()},
+		stat{"Unsatisfied", w.NUnsat()})
+
+	if !ok {
+		report(conf, statusUnknown, nil, stats)
+	}
+	report(conf, statusSAT, [][]int{w.Answer()}, stats)
 }
 
 func parseFlags(c *config.Config) {
 	flag.UintVar(&c.Models, "m", uint(1), "number of models to find")
-	flag.Float64Var(&c.VarDecay, "decay-var", 0.95, "variable decay constant")
-	flag.Float64Var(&c.VarDecay, "decay-cla", 0.999, "clause decay constant")
+	flag.BoolVar(&c.LocalSearchOnly, "sls", false,
+		"solve with local search alone, which can't prove unsatisfiability")
+	flag.IntVar(&c.MaxFlips, "max-flips", 0,
+		"maximum number of flips for -sls, 0 for no limit")
+	flag.BoolVar(&trace, "trace", false, "write search events to stderr")
+	solverFlags(flag.CommandLine, c)
+	ioFlags(flag.CommandLine)
 	flag.Usage = flagUsage
 	flag.Parse()
 
@@ -87,28 +153,104 @@ func parseFlags(c *config.Config) {
 	}
 }
 
+// solverFlags defines the flags configuring solvers on fs, defaulting to the
+// values in c.
+func solverFlags(fs *flag.FlagSet, c *config.Config) {
+	fs.Float64Var(&c.VarDecay, "decay-var", c.VarDecay, "variable decay constant")
+	fs.Float64Var(&c.ClaDecay, "decay-cla", c.ClaDecay, "clause decay constant")
+	fs.IntVar(&c.CCMinMode, "ccmin", c.CCMinMode,
+		"learnt clause minimization (0=none, 1=local, 2=recursive)")
+	fs.StringVar(&c.Restarts, "restarts", c.Restarts,
+		"restart policy (geometric, luby, glucose)")
+	fs.StringVar(&c.Polarity, "polarity", c.Polarity,
+		"default decision polarity (true, false, random)")
+	fs.BoolVar(&c.PhaseSaving, "phase-saving", c.PhaseSaving, "save variable phases")
+	fs.BoolVar(&c.Rephase, "rephase", c.Rephase,
+		"use target phases and periodically rephase")
+	fs.StringVar(&c.Branching, "branching", c.Branching,
+		"branching heuristic (vsids, vmtf, lrb)")
+	fs.StringVar(&c.StableBranching, "stable-branching", c.StableBranching,
+		"branching heuristic for stable mode, alternating with focused mode")
+	fs.IntVar(&c.Chrono, "chrono", c.Chrono,
+		"backjump distance above which to backtrack chronologically, 0 disables")
+	fs.IntVar(&c.Threads, "threads", c.Threads,
+		"number of solvers to run in parallel")
+	fs.Int64Var(&c.Seed, "seed", c.Seed, "random number generator seed")
+	fs.Float64Var(&c.RandomFreq, "random-freq", c.RandomFreq,
+		"frequency of decisions on random variables")
+	fs.StringVar(&c.LocalSearch, "sls-algorithm", c.LocalSearch,
+		"local search algorithm (probsat, walksat)")
+	fs.BoolVar(&c.LocalSearchRephase, "sls-rephase", c.LocalSearchRephase,
+		"improve the best phases by local search when rephasing")
+}
+
+// ioFlags defines the flags configuring input and output on fs.
+func ioFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&lenient, "lenient", lenient,
+		"accept DIMACS input without a header, with inaccurate counts or a missing final 0")
+	fs.StringVar(&format, "format", format, "output format (text, competition, json)")
+}
+
 func flagUsage() {
 	fmt.Fprintf(os.Stderr, "Usage: saturday input.cnf [args]"+
+		"\n\nThe input may be compressed with gzip, bzip2 or xz, or - to read"+
+		" standard input. Input in the iCNF format is solved incrementally, and"+
+		" QDIMACS input as a QBF. For AIGER input, whether an output can be true"+
+		" is solved, and sequential circuits are checked by the bmc command."+
 		"\n\nValid Arguments:\n")
 	flag.PrintDefaults()
 }
 
-func readCNF(path string) ([][]int, error) {
-	f, err := os.Open(path)
+// readCNF reads a possibly compressed CNF from path, or from standard input
+// when path is "-". Parsing is strict unless lenient is set.
+func readCNF(path string, lenient bool) ([][]int, error) {
+	in, err := openInput(path)
 	if err != nil {
 		return nil, err
 	}
-	if !isFile(path) {
-		return nil, fmt.Errorf("open %s: not a readable file", path)
+	prefix, sentences, err := parseCNF(in, path, lenient)
+	if err != nil {
+		return nil, err
+	}
+	if len(prefix) > 0 {
+		return nil, fmt.Errorf("%s: quantified input isn't supported", path)
 	}
-	return encoding.ParseDimacs(bufio.NewReader(f))
+	return sentences, nil
 }
 
-func isFile(path string) bool {
-	if fs, err := os.Stat(path); err == nil {
-		if fs.Mode().IsRegular() {
-			return true
+// openInput opens path, or standard input when path is "-", decompressing it
+// if needed.
+func openInput(path string) (*bufio.Reader, error) {
+	in := os.Stdin
+
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		if fi, err := f.Stat(); err != nil {
+			return nil, err
+		} else if fi.IsDir() {
+			return nil, fmt.Errorf("open %s: is a directory", path)
 		}
+		in = f
+	}
+	r, err := encoding.Decompress(in)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	return bufio.NewReaderSize(r, inputBufferSize), nil
+}
+
+// parseCNF parses CNF, or QBF with its quantifier prefix, read from path.
+// Parsing is strict unless lenient is set.
+func parseCNF(in io.Reader, path string, lenient bool) ([]encoding.Quantifier, [][]int, error) {
+	p := encoding.NewDimacsParser(in)
+	p.Lenient = lenient
+
+	sentences, err := p.Parse()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s:%w", path, err)
 	}
-	return false
+	return p.Prefix, sentences, nil
 }
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"github.com/ericr/saturday/config"
+	"github.com/ericr/saturday/solver"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Output formats.
+const (
+	// formatText writes the result and statistics to standard error and models
+	// to standard output.
+	formatText = "text"
+	// formatCompetition writes everything to standard output as in the SAT
+	// competition, with exit codes 10 for SAT, 20 for UNSAT and 0 otherwise.
+	formatCompetition = "competition"
+	// formatJSON writes a single JSON document to standard output, with the
+	// same exit codes as formatText.
+	formatJSON = "json"
+)
+
+// vLineWidth is the maximum width of model lines in competition output.
+const vLineWidth = 78
+
+// status is the outcome of a run.
+type status int
+
+const (
+	statusUnknown status = iota
+	statusSAT
+	statusUNSAT
+)
+
+// String returns the status as reported in competition and JSON output.
+func (st status) String() string {
+	switch st {
+	case statusSAT:
+		return "SATISFIABLE"
+	case statusUNSAT:
+		return "UNSATISFIABLE"
+	}
+	return "UNKNOWN"
+}
+
+// stat is a named statistic reported after a run. Values are either counts or
+// durations.
+type stat struct {
+	name  string
+	value interface{}
+}
+
+// String formats the stat's value.
+func (s stat) String() string {
+	if d, ok := s.value.(time.Duration); ok {
+		return fmt.Sprintf("%fs", d.Seconds())
+	}
+	return fmt.Sprint(s.value)
+}
+
+// key returns the stat's name as a JSON object key.
+func (s stat) key() string {
+	return strings.ToLower(strings.ReplaceAll(s.name, " ", "_"))
+}
+
+// jsonValue returns the stat's value as a JSON value, with durations in
+// seconds.
+func (s stat) jsonValue() interface{} {
+	if d, ok := s.value.(time.Duration); ok {
+		return d.Seconds()
+	}
+	return s.value
+}
+
+// solverStats returns the statistics of s after solving for t.
+func solverStats(s *solver.Solver, t time.Duration) []stat {
+	return []stat{
+		{"Time Taken", t},
+		{"Variables", s.NVars()},
+		{"Constraints", s.NConstrs()},
+		{"Learnts", s.NLearnts()},
+		{"Assignments", s.NAssigns()},
+		{"Conflicts", s.NConflicts()},
+		{"Propagations", s.NPropagations()},
+		{"Restarts", s.NRestarts()},
+		{"Decisions", s.NDecisions()},
+	}
+}
+
+// timeStats returns the statistics of a run taking t.
+func timeStats(t time.Duration) []stat {
+	return []stat{{"Time Taken", t}}
+}
+
+// report writes the outcome of a run in the configured format and exits.
+func report(c *config.Config, st status, models [][]int, stats []stat) {
+	writeResult(c, st, models, nil, stats)
+	os.Exit(exitCode(st))
+}
+
+// writeResult writes the outcome of a run, or of a query of an incremental
+// run, in the configured format. failed are the assumptions refuted by an
+// unsatisfiable query, which only JSON output reports.
+func writeResult(c *config.Config, st status, models [][]int, failed []int, stats []stat) {
+	switch format {
+	case formatCompetition:
+		w := bufio.NewWriter(os.Stdout)
+		writeCompetition(w, st, models, stats)
+		w.Flush()
+	case formatJSON:
+		if err := writeJSON(os.Stdout, c, st, models, failed, stats); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	default:
+		writeText(os.Stderr, os.Stdout, st, models, stats)
+	}
+}
+
+// writeText writes stats and the status to info, and models to out.
+func writeText(info, out io.Writer, st status, models [][]int, stats []stat) {
+	fmt.Fprint(info, "\n")
+	for _, s := range stats {
+		fmt.Fprintf(info, "%-13s: %s\n", s.name, s)
+	}
+	fmt.Fprint(info, "\n")
+
+	switch st {
+	case statusSAT:
+		fmt.Fprint(info, "SAT\n")
+	case statusUNSAT:
+		fmt.Fprint(info, "UNSAT\n")
+	default:
+		fmt.Fprint(info, "UNKNOWN\n")
+	}
+	for _, model := range models {
+		for _, p := range model {
+			fmt.Fprintf(out, "%d ", p)
+		}
+		fmt.Fprint(out, "0\n")
+	}
+}
+
+// writeCompetition writes stats as comment lines, followed by the status line
+// and models as value lines.
+func writeCompetition(w io.Writer, st status, models [][]int, stats []stat) {
+	for _, s := range stats {
+		fmt.Fprintf(w, "c %s: %s\n", s.name, s)
+	}
+	fmt.Fprintf(w, "s %s\n", st)
+
+	for _, model := range models {
+		writeValues(w, model)
+	}
+}
+
+// writeValues writes a model as value lines no wider than vLineWidth,
+// terminated by 0.
+func writeValues(w io.Writer, model []int) {
+	line := []byte("v")
+
+	for i := 0; i <= len(model); i++ {
+		tok := "0"
+		if i < len(model) {
+			tok = strconv.Itoa(model[i])
+		}
+		if len(line)+1+len(tok) > vLineWidth {
+			line = append(line, '\n')
+			w.Write(line)
+			line = append(line[:0], 'v')
+		}
+		line = append(line, ' ')
+		line = append(line, tok...)
+	}
+	line = append(line, '\n')
+	w.Write(line)
+}
+
+// jsonResult is the document written in JSON output.
+type jsonResult struct {
+	Status string  `json:"status"`
+	Models [][]int `json:"models"`
+	// Failed are the failed assumptions of an unsatisfiable query.
+	Failed []int                  `json:"failed,omitempty"`
+	Stats  map[string]interface{} `json:"stats"`
+	Config *config.Config         `json:"config"`
+}
+
+// writeJSON writes the outcome of a run as a single JSON document.
+func writeJSON(w io.Writer, c *config.Config, st status, models [][]int, failed []int,
+	stats []stat) error {
+	res := jsonResult{
+		Status: st.String(),
+		Models: models,
+		Failed: failed,
+		Stats:  map[string]interface{}{},
+		Config: c,
+	}
+	if res.Models == nil {
+		res.Models = [][]int{}
+	}
+	for _, s := range stats {
+		res.Stats[s.key()] = s.jsonValue()
+	}
+	return json.NewEncoder(w).Encode(res)
+}
+
+// exitCode returns the exit code of st in the configured format.
+func exitCode(st status) int {
+	if format == formatCompetition {
+		return competitionExitCode(st)
+	}
+	return textExitCode(st)
+}
+
+// textExitCode returns the exit code of st in text output.
+func textExitCode(st status) int {
+	switch st {
+	case statusSAT:
+		return 0
+	case statusUNSAT:
+		return 3
+	}
+	return 4
+}
+
+// competitionExitCode returns the exit code of st in competition output.
+func competitionExitCode(st status) int {
+	switch st {
+	case statusSAT:
+		return 10
+	case statusUNSAT:
+		return 20
+	}
+	return 0
+}
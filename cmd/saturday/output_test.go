@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"github.com/ericr/saturday/config"
+	"github.com/ericr/saturday/solver"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// testStats are stats with fixed values.
+var testStats = []stat{
+	{"Time Taken", 1500 * time.Millisecond},
+	{"Conflicts", 42},
+}
+
+// longModel is a model spanning several value lines.
+var longModel = func() []int {
+	model := []int{}
+	for v := 1; v <= 40; v++ {
+		if v%3 == 0 {
+			model = append(model, -v)
+		} else {
+			model = append(model, v)
+		}
+	}
+	return model
+}()
+
+// golden compares got with the golden file name in testdata, or updates the
+// file when the -update flag is set.
+func golden(t *testing.T, name string, got []byte) {
+	path := filepath.Join("testdata", name+".golden")
+
+	if *update {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatalf("golden() failed, got: %v", err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("golden() failed, got: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("golden() failed on %s, got:\n%s\nwant:\n%s", name, got, want)
+	}
+}
+
+// captureStdout returns what f writes to standard output.
+func captureStdout(t *testing.T, f func()) []byte {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("captureStdout() failed, got: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+
+	defer func() {
+		os.Stdout = stdout
+	}()
+	f()
+	w.Close()
+
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("captureStdout() failed, got: %v", err)
+	}
+	return out
+}
+
+func TestWriteCompetition(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		st     status
+		models [][]int
+	}{
+		{"competition_sat", statusSAT, [][]int{longModel}},
+		{"competition_unsat", statusUNSAT, nil},
+		{"competition_unknown", statusUnknown, nil},
+	} {
+		buf := bytes.Buffer{}
+		writeCompetition(&buf, test.st, test.models, testStats)
+		golden(t, test.name, buf.Bytes())
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	info, out := bytes.Buffer{}, bytes.Buffer{}
+	writeText(&info, &out, statusSAT, [][]int{{1, -2, 3}, {-1, 2, 3}}, testStats)
+
+	golden(t, "text_sat_info", info.Bytes())
+	golden(t, "text_sat_models", out.Bytes())
+}
+
+func TestWriteJSON(t *testing.T) {
+	conf := config.New()
+
+	for _, test := range []struct {
+		name   string
+		st     status
+		models [][]int
+		failed []int
+	}{
+		{"json_sat", statusSAT, [][]int{{1, -2, 3}}, nil},
+		{"json_unsat", statusUNSAT, nil, []int{-1, 3}},
+	} {
+		buf := bytes.Buffer{}
+
+		if err := writeJSON(&buf, conf, test.st, test.models, test.failed, testStats); err != nil {
+			t.Fatalf("TestWriteJSON() failed, got: %v", err)
+		}
+		golden(t, test.name, buf.Bytes())
+	}
+}
+
+func TestQueryFailed(t *testing.T) {
+	conf := config.New()
+	conf.Logger = log.New(io.Discard, "", 0)
+	format = formatJSON
+	defer func() {
+		format = formatText
+	}()
+	sat := solver.New(conf)
+
+	sat.AddClause([]int{-1, 2})
+	sat.AddClause([]int{-2, -3})
+
+	for _, test := range []struct {
+		assumps []int
+		st      status
+		failed  string
+	}{
+		{[]int{1, 4}, statusSAT, "null"},
+		{[]int{4, 1, 3}, statusUNSAT, "[1,3]"},
+	} {
+		st := statusUnknown
+		out := captureStdout(t, func() {
+			st = query(sat, test.assumps, conf)
+		})
+		res := map[string]json.RawMessage{}
+
+		if err := json.Unmarshal(out, &res); err != nil {
+			t.Fatalf("TestQueryFailed() failed, got: %v", err)
+		}
+		if st != test.st {
+			t.Fatalf("TestQueryFailed() failed, got: %v", st)
+		}
+		if failed, ok := res["failed"]; (ok && string(failed) != test.failed) ||
+			(!ok && test.failed != "null") {
+			t.Fatalf("TestQueryFailed() failed, got: %s", failed)
+		}
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	for _, test := range []struct {
+		st          status
+		text        int
+		competition int
+	}{
+		{statusSAT, 0, 10},
+		{statusUNSAT, 3, 20},
+		{statusUnknown, 4, 0},
+	} {
+		if got := textExitCode(test.st); got != test.text {
+			t.Fatalf("TestExitCode() failed on %v, got: %d", test.st, got)
+		}
+		if got := competitionExitCode(test.st); got != test.competition {
+			t.Fatalf("TestExitCode() failed on %v, got: %d", test.st, got)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"github.com/ericr/saturday/config"
+	"github.com/ericr/saturday/encoding"
+	"github.com/ericr/saturday/qbf"
+	"github.com/ericr/saturday/solver"
+	"time"
+)
+
+// solveQBF solves a quantified boolean formula, reporting it as satisfiable
+// when it's true along with the certificate of its outermost block.
+func solveQBF(prefix []encoding.Quantifier, sentences [][]int, conf *config.Config) {
+	conf.Logger.Printf("Starting Saturday %s QBF solver", solver.Version())
+
+	tStart := time.Now()
+	q := qbf.New(prefix, sentences, conf)
+	ok := q.Solve()
+
+	conf.Logger.Print("Finished solving")
+
+	stats := append(timeStats(time.Now().Sub(tStart)),
+		stat{"Levels", q.NLevels()},
+		stat{"Refinements", q.NRefinements()})
+	models := [][]int{}
+
+	if len(q.Certificate()) > 0 {
+		models = append(models, q.Certificate())
+	}
+	if !ok {
+		report(conf, statusUNSAT, models, stats)
+	}
+	report(conf, statusSAT, models, stats)
+}
@@ -5,15 +5,101 @@ import (
 	"os"
 )
 
+// Conflict clause minimization modes.
+const (
+	// CCMinNone disables learnt clause minimization.
+	CCMinNone = iota
+	// CCMinLocal removes literals implied by their immediate reason.
+	CCMinLocal
+	// CCMinRecursive removes literals implied transitively by the clause.
+	CCMinRecursive
+)
+
+// Restart policies.
+const (
+	// RestartsGeometric restarts after geometrically growing conflict limits.
+	RestartsGeometric = "geometric"
+	// RestartsLuby restarts after conflict limits following the Luby sequence.
+	RestartsLuby = "luby"
+	// RestartsGlucose restarts based on the moving average of learnt clause
+	// literal block distance.
+	RestartsGlucose = "glucose"
+)
+
+// Default polarities.
+const (
+	// PolarityTrue decides variables as true first.
+	PolarityTrue = "true"
+	// PolarityFalse decides variables as false first.
+	PolarityFalse = "false"
+	// PolarityRandom decides variables randomly.
+	PolarityRandom = "random"
+)
+
+// Branching heuristics.
+const (
+	// BranchingVSIDS branches on variables with the highest decaying activity.
+	BranchingVSIDS = "vsids"
+	// BranchingVMTF branches on the most recently bumped variables.
+	BranchingVMTF = "vmtf"
+	// BranchingLRB branches on variables with the highest learning rate.
+	BranchingLRB = "lrb"
+)
+
+// Local search algorithms.
+const (
+	// LocalSearchProbSAT flips variables with a probability based on how many
+	// clauses they'd break.
+	LocalSearchProbSAT = "probsat"
+	// LocalSearchWalkSAT flips variables that break the fewest clauses, with
+	// random walk steps.
+	LocalSearchWalkSAT = "walksat"
+)
+
+// Config configures a solver. A Config may be shared by concurrent solvers as
+// long as it isn't modified; Clone returns a copy that can be.
 type Config struct {
-	Logger   *log.Logger
-	VarDecay float64
-	ClaDecay float64
-	Models   uint
+	Logger             *log.Logger `json:"-"`
+	VarDecay           float64
+	ClaDecay           float64
+	Models             uint
+	CCMinMode          int
+	Restarts           string
+	Polarity           string
+	PhaseSaving        bool
+	Rephase            bool
+	Branching          string
+	StableBranching    string
+	Chrono             int
+	Threads            int
+	LocalSearch        string
+	LocalSearchOnly    bool
+	LocalSearchRephase bool
+	MaxFlips           int
+	Seed               int64
+	RandomFreq         float64
 }
 
 func New() *Config {
 	return &Config{
-		Logger: log.New(os.Stderr, "", log.Ldate|log.Ltime),
+		Logger:      log.New(os.Stderr, "", log.Ldate|log.Ltime),
+		VarDecay:    0.95,
+		ClaDecay:    0.999,
+		CCMinMode:   CCMinRecursive,
+		Restarts:    RestartsGeometric,
+		Polarity:    PolarityTrue,
+		PhaseSaving: true,
+		Rephase:     true,
+		Branching:   BranchingVSIDS,
+		Chrono:      100,
+		Threads:     1,
+		LocalSearch: LocalSearchProbSAT,
 	}
 }
+
+// Clone returns a copy of the config. The copy shares the logger, which is
+// safe for concurrent use.
+func (c *Config) Clone() *Config {
+	clone := *c
+	return &clone
+}
@@ -0,0 +1,36 @@
+package encoding
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+)
+
+// Magic bytes identifying compressed streams.
+var (
+	gzipMagic  = []byte{0x1f, 0x8b}
+	bzip2Magic = []byte("BZh")
+	xzMagic    = []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}
+)
+
+// Decompress returns a reader decompressing in when it starts with the magic
+// bytes of gzip, bzip2 or xz, and a reader of in as is otherwise.
+func Decompress(in io.Reader) (io.Reader, error) {
+	r := bufio.NewReader(in)
+	magic, err := r.Peek(len(xzMagic))
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		return gzip.NewReader(r)
+	case bytes.HasPrefix(magic, bzip2Magic):
+		return bzip2.NewReader(r), nil
+	case bytes.HasPrefix(magic, xzMagic):
+		return newXZReader(r)
+	}
+	return r, nil
+}
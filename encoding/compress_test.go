@@ -0,0 +1,78 @@
+package encoding
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// readTestdata returns the contents of the file name in testdata.
+func readTestdata(t *testing.T, name string) []byte {
+	b, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("readTestdata() failed, got: %v", err)
+	}
+	return b
+}
+
+// decompressAll returns the decompressed contents of in.
+func decompressAll(in []byte) ([]byte, error) {
+	r, err := Decompress(bytes.NewReader(in))
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+func TestDecompress(t *testing.T) {
+	want := readTestdata(t, "sample.cnf")
+
+	for _, name := range []string{
+		"sample.cnf",
+		"sample.cnf.gz",
+		"sample.cnf.bz2",
+		"sample.cnf.xz",
+		"sample-crc32.cnf.xz",
+		"sample-none.cnf.xz",
+		"sample-sha256.cnf.xz",
+		"sample-blocks.cnf.xz",
+		"sample-concat.cnf.xz",
+	} {
+		got, err := decompressAll(readTestdata(t, name))
+		if err != nil {
+			t.Fatalf("TestDecompress() failed on %s, got: %v", name, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("TestDecompress() failed on %s, got: %q", name, got)
+		}
+	}
+
+	// Input shorter than the longest magic bytes is read as is.
+	got, err := decompressAll([]byte("p"))
+	if err != nil || string(got) != "p" {
+		t.Fatalf("TestDecompress() failed, got: %q, %v", got, err)
+	}
+}
+
+func TestDecompressCorruptXZ(t *testing.T) {
+	in := readTestdata(t, "sample.cnf.xz")
+
+	// Every truncation of the stream is an error.
+	for n := len(xzMagic); n < len(in); n++ {
+		if _, err := decompressAll(in[:n]); err == nil {
+			t.Fatalf("TestDecompressCorruptXZ() failed on %d bytes, got: nil", n)
+		}
+	}
+	// So is a change to any byte, which the header, block and index checks
+	// detect.
+	for i := len(xzMagic); i < len(in); i++ {
+		corrupt := append([]byte{}, in...)
+		corrupt[i] ^= 0x01
+
+		if _, err := decompressAll(corrupt); err == nil {
+			t.Fatalf("TestDecompressCorruptXZ() failed at byte %d, got: nil", i)
+		}
+	}
+}
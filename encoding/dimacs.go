@@ -2,37 +2,358 @@ package encoding
 
 import (
 	"bufio"
-	"bytes"
+	"fmt"
 	"io"
 	"strconv"
 )
 
-func ParseDimacs(in io.Reader) ([][]int, error) {
-	scanner := bufio.NewScanner(in)
-	sentences := [][]int{}
+// ParseError is an error at a position of the input. Lines and columns start
+// at 1.
+type ParseError struct {
+	Line int
+	Col  int
+	Msg  string
+}
+
+// Error implements the error interface.
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Col, e.Msg)
+}
+
+// scanner splits DIMACS input into whitespace separated tokens, keeping track
+// of their positions.
+type scanner struct {
+	r *bufio.Reader
+	// line and col are the position of the next byte.
+	line int
+	col  int
+	// tokLine and tokCol are the position of the last token.
+	tokLine int
+	tokCol  int
+	// first is whether the last token is the first on its line.
+	first bool
+	tok   []byte
+}
 
-	for scanner.Scan() {
-		sentence := []int{}
-		fields := bytes.Fields(scanner.Bytes())
+// newScanner returns a new scanner reading from in.
+func newScanner(in io.Reader) *scanner {
+	return &scanner{r: bufio.NewReader(in), line: 1, col: 1}
+}
+
+// next reads the next token, returning io.EOF at the end of the input.
+func (s *scanner) next() ([]byte, error) {
+	s.first = s.col == 1
+	s.tok = s.tok[:0]
 
-		if len(fields) < 2 {
+	for {
+		b, err := s.r.ReadByte()
+		if err == io.EOF && len(s.tok) > 0 {
+			return s.tok, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !isSpace(b) {
+			if len(s.tok) == 0 {
+				s.tokLine, s.tokCol = s.line, s.col
+			}
+			s.tok = append(s.tok, b)
+			s.col++
 			continue
 		}
-		prefix := string(fields[0])
+		if len(s.tok) > 0 {
+			// Leave the separator for the next call, so that a newline still
+			// marks the next token as first on its line.
+			s.r.UnreadByte()
+			return s.tok, nil
+		}
+		s.advance(b)
+		if b == '\n' {
+			s.first = true
+		}
+	}
+}
+
+// advance moves the position past b.
+func (s *scanner) advance(b byte) {
+	if b == '\n' {
+		s.line++
+		s.col = 1
+	} else {
+		s.col++
+	}
+}
+
+// skipLine discards the rest of the current line.
+func (s *scanner) skipLine() error {
+	for {
+		b, err := s.r.ReadByte()
+		if err != nil {
+			return err
+		}
+		s.advance(b)
+
+		if b == '\n' {
+			return nil
+		}
+	}
+}
+
+// int parses the last token as an integer.
+func (s *scanner) int(what string) (int, error) {
+	n, err := strconv.Atoi(string(s.tok))
+	if err != nil {
+		return 0, s.errorf("invalid %s %q", what, s.tok)
+	}
+	return n, nil
+}
+
+// errorf returns a ParseError at the position of the last token.
+func (s *scanner) errorf(format string, args ...interface{}) error {
+	return &ParseError{s.tokLine, s.tokCol, fmt.Sprintf(format, args...)}
+}
+
+// isSpace returns whether b separates tokens.
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r' || b == '\v' || b == '\f'
+}
+
+// Quantifier is a block of variables bound by the same quantifier.
+type Quantifier struct {
+	Universal bool
+	Vars      []int
+}
+
+// DimacsParser parses CNF in the DIMACS format, or QBF in the QDIMACS format
+// when the header is followed by quantifier lines. Clauses may span lines and
+// share them, and each is terminated by 0. Lines starting with c are comments.
+type DimacsParser struct {
+	// Lenient accepts input without a header or with inaccurate counts, a final
+	// clause lacking its terminating 0, and input ending with a % line as in
+	// SATLIB.
+	Lenient bool
+	// NVars and NClauses are the counts declared by the header.
+	NVars    int
+	NClauses int
+	// Prefix is the quantifier prefix of QDIMACS input, outermost first.
+	Prefix []Quantifier
+
+	s          *scanner
+	header     bool
+	quantified map[int]bool
+}
+
+// NewDimacsParser returns a new strict parser reading from in.
+func NewDimacsParser(in io.Reader) *DimacsParser {
+	return &DimacsParser{s: newScanner(in), quantified: map[int]bool{}}
+}
+
+// ParseDimacs parses CNF in the DIMACS format, validating it strictly.
+func ParseDimacs(in io.Reader) ([][]int, error) {
+	return NewDimacsParser(in).Parse()
+}
+
+// ParseQDimacs parses QBF in the QDIMACS format, validating it strictly. It
+// returns the quantifier prefix, outermost first, and the clauses.
+func ParseQDimacs(in io.Reader) ([]Quantifier, [][]int, error) {
+	p := NewDimacsParser(in)
+
+	sentences, err := p.Parse()
+	if err != nil {
+		return nil, nil, err
+	}
+	return p.Prefix, sentences, nil
+}
+
+// Parse reads all clauses. In strict mode the input must start with a
+// `p cnf V C` header, after comments, and contain exactly C clauses over
+// variables up to V.
+func (p *DimacsParser) Parse() ([][]int, error) {
+	sentences := [][]int{}
+	sentence := []int{}
+	s := p.s
+
+	for {
+		tok, err := s.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
 
-		if prefix == "c" || prefix == "p" {
+		if s.first && tok[0] == 'c' {
+			if err := s.skipLine(); err != nil && err != io.EOF {
+				return nil, err
+			}
 			continue
 		}
-		for _, field := range fields[:len(fields)] {
-			p, err := strconv.Atoi(string(field))
-			if err != nil {
+		if s.first && string(tok) == "p" {
+			if err := p.parseHeader(len(sentences) > 0 || len(sentence) > 0); err != nil {
 				return nil, err
 			}
-			if p != 0 {
-				sentence = append(sentence, p)
+			continue
+		}
+		if s.first && (string(tok) == "a" || string(tok) == "e") {
+			if err := p.parseQuantifier(string(tok) == "a", len(sentences) > 0 || len(sentence) > 0); err != nil {
+				return nil, err
 			}
+			continue
+		}
+		if p.Lenient && s.first && tok[0] == '%' {
+			break
+		}
+		if !p.header && !p.Lenient {
+			return nil, s.errorf("expected header, got %q", tok)
+		}
+		n, err := s.int("literal")
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			sentences = append(sentences, sentence)
+			sentence = []int{}
+			continue
+		}
+		if !p.Lenient && (n > p.NVars || -n > p.NVars) {
+			return nil, s.errorf("literal %d exceeds %d variables", n, p.NVars)
+		}
+		sentence = append(sentence, n)
+	}
+
+	if len(sentence) > 0 {
+		if !p.Lenient {
+			return nil, s.errorf("clause not terminated by 0")
 		}
 		sentences = append(sentences, sentence)
 	}
+	if !p.Lenient {
+		if !p.header {
+			return nil, &ParseError{s.line, s.col, "missing header"}
+		}
+		if len(sentences) != p.NClauses {
+			return nil, &ParseError{s.line, s.col, fmt.Sprintf(
+				"header declares %d clauses, got %d", p.NClauses, len(sentences))}
+		}
+	}
 	return sentences, nil
 }
+
+// parseHeader parses the rest of a `p cnf V C` line. late is whether clauses
+// were read before it.
+func (p *DimacsParser) parseHeader(late bool) error {
+	s := p.s
+
+	if p.header {
+		return s.errorf("duplicate header")
+	}
+	if late && !p.Lenient {
+		return s.errorf("header after clauses")
+	}
+	p.header = true
+
+	fields := []string{"format", "variable count", "clause count"}
+	counts := []int{}
+
+	for i, what := range fields {
+		line, col := s.line, s.col
+
+		tok, err := s.next()
+		if err == io.EOF || (err == nil && s.first) {
+			return &ParseError{line, col, "missing " + what + " in header"}
+		}
+		if err != nil {
+			return err
+		}
+		if i == 0 {
+			if string(tok) != "cnf" {
+				return s.errorf("unsupported format %q", tok)
+			}
+			continue
+		}
+		n, err := s.int(what)
+		if err != nil {
+			return err
+		}
+		if n < 0 {
+			return s.errorf("negative %s %d", what, n)
+		}
+		counts = append(counts, n)
+	}
+	p.NVars, p.NClauses = counts[0], counts[1]
+
+	return nil
+}
+
+// parseQuantifier parses the rest of a quantifier line. late is whether
+// clauses were read before it.
+func (p *DimacsParser) parseQuantifier(universal bool, late bool) error {
+	s := p.s
+
+	if !p.header && !p.Lenient {
+		return s.errorf("quantifier before header")
+	}
+	if late {
+		return s.errorf("quantifier after clauses")
+	}
+	vars := []int{}
+
+	for {
+		line, col := s.line, s.col
+
+		_, err := s.next()
+		if err == io.EOF || (err == nil && s.first) {
+			return &ParseError{line, col, "quantifier not terminated by 0"}
+		}
+		if err != nil {
+			return err
+		}
+		v, err := s.int("variable")
+		if err != nil {
+			return err
+		}
+		if v == 0 {
+			break
+		}
+		if v < 0 || (!p.Lenient && v > p.NVars) {
+			return s.errorf("invalid variable %d", v)
+		}
+		if p.quantified[v] {
+			return s.errorf("variable %d quantified twice", v)
+		}
+		p.quantified[v] = true
+		vars = append(vars, v)
+	}
+
+	// Merge blocks with the same quantifier.
+	if n := len(p.Prefix); n > 0 && p.Prefix[n-1].Universal == universal {
+		p.Prefix[n-1].Vars = append(p.Prefix[n-1].Vars, vars...)
+	} else if len(vars) > 0 {
+		p.Prefix = append(p.Prefix, Quantifier{universal, vars})
+	}
+	return nil
+}
+
+// WriteDimacs writes clauses in the DIMACS format, with a header declaring the
+// highest variable used.
+func WriteDimacs(out io.Writer, clauses [][]int) error {
+	w := bufio.NewWriter(out)
+	nVars := 0
+
+	for _, clause := range clauses {
+		for _, p := range clause {
+			if p < 0 {
+				p = -p
+			}
+			if p > nVars {
+				nVars = p
+			}
+		}
+	}
+	fmt.Fprintf(w, "p cnf %d %d\n", nVars, len(clauses))
+
+	for _, clause := range clauses {
+		writeLits(w, "", clause)
+	}
+	return w.Flush()
+}
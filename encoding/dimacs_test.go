@@ -0,0 +1,157 @@
+package encoding
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseDimacs(t *testing.T) {
+	for _, test := range []struct {
+		in      string
+		lenient bool
+		want    string
+		err     string
+	}{
+		// Clauses may span and share lines.
+		{"c comment\np cnf 3 2\n1 -2\n3 0 -1 0\n", false, "[[1 -2 3] [-1]]", ""},
+		{"p cnf 2 1\n\t1  2 0", false, "[[1 2]]", ""},
+		{"p cnf 0 1\n0\n", false, "[[]]", ""},
+		{"p cnf 0 0\n", false, "[]", ""},
+		{"p cnf 2 1\nc 1 0\n1 0\n", false, "[[1]]", ""},
+
+		// Strict validation.
+		{"1 2 0\n", false, "", "1:1: expected header, got \"1\""},
+		{"c only a comment\n", false, "", "2:1: missing header"},
+		{"p cnf 2 1\n1 2 0\n1 0\n", false, "", "4:1: header declares 1 clauses, got 2"},
+		{"p cnf 2 3\n1 2 0\n", false, "", "3:1: header declares 3 clauses, got 1"},
+		{"p cnf 2 1\n1 3 0\n", false, "", "2:3: literal 3 exceeds 2 variables"},
+		{"p cnf 2 1\n1 -3 0\n", false, "", "2:3: literal -3 exceeds 2 variables"},
+		{"p cnf 2 1\n1 2\n", false, "", "2:3: clause not terminated by 0"},
+		{"p cnf 2 1\n1 x 0\n", false, "", "2:3: invalid literal \"x\""},
+		{"p cnf 2 1\n1 2 0\n%\n0\n", false, "", "3:1: invalid literal \"%\""},
+		{"p cnf 2 1\np cnf 2 1\n1 0\n", false, "", "2:1: duplicate header"},
+		{"1 0\np cnf 2 1\n", false, "", "1:1: expected header, got \"1\""},
+		{"p cnf 2 1\nc x\n1 0\np cnf 2 1\n", false, "", "4:1: duplicate header"},
+		{"p dnf 2 1\n", false, "", "1:3: unsupported format \"dnf\""},
+		{"p cnf 2\n1 0\n", false, "", "1:8: missing clause count in header"},
+		{"p cnf\n", false, "", "1:6: missing variable count in header"},
+		{"p cnf -2 1\n", false, "", "1:7: negative variable count -2"},
+		{"p cnf 2 y\n", false, "", "1:9: invalid clause count \"y\""},
+
+		// Lenient parsing.
+		{"1 2 0\n-1 0\n", true, "[[1 2] [-1]]", ""},
+		{"p cnf 1 1\n1 2 0\n-1 0\n", true, "[[1 2] [-1]]", ""},
+		{"p cnf 2 1\n1 2 0\n-2", true, "[[1 2] [-2]]", ""},
+		{"1 0\np cnf 2 1\n-1 0\n", true, "[[1] [-1]]", ""},
+		{"p cnf 2 1\n1 2 0\n%\n0\n\n", true, "[[1 2]]", ""},
+		{"p cnf 2 1\n1 2 0 %\n", true, "", "2:7: invalid literal \"%\""},
+	} {
+		p := NewDimacsParser(strings.NewReader(test.in))
+		p.Lenient = test.lenient
+
+		clauses, err := p.Parse()
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Fatalf("TestParseDimacs() failed on %q, got: %v", test.in, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("TestParseDimacs() failed on %q, got: %v", test.in, err)
+		}
+		if got := fmt.Sprint(clauses); got != test.want {
+			t.Fatalf("TestParseDimacs() failed on %q, got: %v", test.in, got)
+		}
+	}
+}
+
+func TestParseErrorPosition(t *testing.T) {
+	_, err := ParseDimacs(strings.NewReader("c x\np cnf 2 1\n  1   -7 0\n"))
+	pe := &ParseError{}
+
+	if !errors.As(err, &pe) {
+		t.Fatalf("TestParseErrorPosition() failed, got: %v", err)
+	}
+	if pe.Line != 3 || pe.Col != 7 {
+		t.Fatalf("TestParseErrorPosition() failed, got: %d:%d", pe.Line, pe.Col)
+	}
+}
+
+func TestParseDimacsHeader(t *testing.T) {
+	p := NewDimacsParser(strings.NewReader("p cnf 5 2\n1 0\n-5 0\n"))
+
+	if _, err := p.Parse(); err != nil {
+		t.Fatalf("TestParseDimacsHeader() failed, got: %v", err)
+	}
+	if p.NVars != 5 || p.NClauses != 2 {
+		t.Fatalf("TestParseDimacsHeader() failed, got: %d %d", p.NVars, p.NClauses)
+	}
+}
+
+func TestParseQDimacs(t *testing.T) {
+	for _, test := range []struct {
+		in     string
+		prefix string
+		want   string
+		err    string
+	}{
+		{"p cnf 4 1\ne 1 0\na 2 0\na 3 0\ne 4 0\n1 2 3 4 0\n",
+			"[{false [1]} {true [2 3]} {false [4]}]", "[[1 2 3 4]]", ""},
+		{"p cnf 3 1\ne 1 2 0\ne 3 0\n1 0\n", "[{false [1 2 3]}]", "[[1]]", ""},
+		{"p cnf 2 1\ne 0\na 1 0\n1 0\n", "[{true [1]}]", "[[1]]", ""},
+		{"p cnf 2 1\n1 0\n", "[]", "[[1]]", ""},
+
+		{"p cnf 2 1\ne 1 0\na 1 0\n1 0\n", "", "", "3:3: variable 1 quantified twice"},
+		{"p cnf 2 1\ne 1 1 0\n1 0\n", "", "", "2:5: variable 1 quantified twice"},
+		{"p cnf 2 1\ne -1 0\n1 0\n", "", "", "2:3: invalid variable -1"},
+		{"p cnf 2 1\na 3 0\n1 0\n", "", "", "2:3: invalid variable 3"},
+		{"p cnf 2 1\ne 1\n", "", "", "2:4: quantifier not terminated by 0"},
+		{"e 1 0\np cnf 2 1\n", "", "", "1:1: quantifier before header"},
+		{"p cnf 2 1\n1 0\ne 1 0\n", "", "", "3:1: quantifier after clauses"},
+	} {
+		prefix, clauses, err := ParseQDimacs(strings.NewReader(test.in))
+
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Fatalf("TestParseQDimacs() failed on %q, got: %v", test.in, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("TestParseQDimacs() failed on %q, got: %v", test.in, err)
+		}
+		if got := fmt.Sprint(prefix); got != test.prefix {
+			t.Fatalf("TestParseQDimacs() failed on %q, got prefix: %v", test.in, got)
+		}
+		if got := fmt.Sprint(clauses); got != test.want {
+			t.Fatalf("TestParseQDimacs() failed on %q, got: %v", test.in, got)
+		}
+	}
+}
+
+func TestWriteDimacs(t *testing.T) {
+	for _, test := range []struct {
+		clauses [][]int
+		want    string
+	}{
+		{[][]int{{1, -3}, {2}, {}}, "p cnf 3 3\n1 -3 0\n2 0\n0\n"},
+		{[][]int{{-4}}, "p cnf 4 1\n-4 0\n"},
+		{[][]int{}, "p cnf 0 0\n"},
+	} {
+		buf := bytes.Buffer{}
+
+		if err := WriteDimacs(&buf, test.clauses); err != nil {
+			t.Fatalf("TestWriteDimacs() failed, got: %v", err)
+		}
+		if buf.String() != test.want {
+			t.Fatalf("TestWriteDimacs() failed, got: %q", buf.String())
+		}
+		clauses, err := ParseDimacs(&buf)
+		if err != nil || fmt.Sprint(clauses) != fmt.Sprint(test.clauses) {
+			t.Fatalf("TestWriteDimacs() failed, got: %v %v", clauses, err)
+		}
+	}
+}
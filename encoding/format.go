@@ -0,0 +1,63 @@
+package encoding
+
+import (
+	"bufio"
+	"bytes"
+)
+
+// Input formats, as declared by headers.
+const (
+	// FormatCNF is the DIMACS CNF format.
+	FormatCNF = "cnf"
+	// FormatICNF is the incremental CNF format.
+	FormatICNF = "inccnf"
+)
+
+// DetectFormat returns the format declared by the header of the input read by
+// r without consuming it, or an empty string when there is no header before
+// the first line that isn't a comment or within the buffer. It reads no
+// further than that line, so it doesn't wait on interactive input. Read errors
+// are left for parsers to report.
+func DetectFormat(r *bufio.Reader) string {
+	// start is the offset of the first line not looked at yet.
+	start := 0
+
+	for n := 1; ; {
+		buf, err := r.Peek(n)
+
+		for {
+			i := bytes.IndexByte(buf[start:], '\n')
+			if i < 0 {
+				break
+			}
+			if format, ok := headerFormat(buf[start : start+i]); ok {
+				return format
+			}
+			start += i + 1
+		}
+		// The rest is the last line when the input ended or the buffer is full.
+		if err != nil {
+			format, _ := headerFormat(buf[start:])
+			return format
+		}
+		// Peek what's buffered already, or wait for one more byte.
+		n = len(buf) + 1
+		if r.Buffered() > n {
+			n = r.Buffered()
+		}
+	}
+}
+
+// headerFormat returns the format declared by line if it's a header, and
+// whether line decides it, i.e. isn't blank or a comment.
+func headerFormat(line []byte) (string, bool) {
+	fields := bytes.Fields(line)
+
+	if len(fields) == 0 || fields[0][0] == 'c' {
+		return "", false
+	}
+	if string(fields[0]) == "p" && len(fields) > 1 {
+		return string(fields[1]), true
+	}
+	return "", true
+}
@@ -0,0 +1,71 @@
+package encoding
+
+import (
+	"bufio"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// chunkReader returns one chunk per read, like interactive input, and records
+// reads past the last chunk, which would block on such input.
+type chunkReader struct {
+	chunks  []string
+	blocked bool
+}
+
+// Read implements the io.Reader interface.
+func (r *chunkReader) Read(p []byte) (int, error) {
+	if len(r.chunks) == 0 {
+		r.blocked = true
+		return 0, errors.New("blocked")
+	}
+	n := copy(p, r.chunks[0])
+	r.chunks[0] = r.chunks[0][n:]
+
+	if len(r.chunks[0]) == 0 {
+		r.chunks = r.chunks[1:]
+	}
+	return n, nil
+}
+
+func TestDetectFormat(t *testing.T) {
+	for _, test := range []struct {
+		in   string
+		want string
+	}{
+		{"p cnf 2 1\n1 2 0\n", FormatCNF},
+		{"c comment\n\n  p  inccnf\n1 0\n", FormatICNF},
+		{"c comment\n1 0\np cnf 1 1\n", ""},
+		{"p inccnf", FormatICNF},
+		{"c comment", ""},
+		{"", ""},
+	} {
+		got := DetectFormat(bufio.NewReader(strings.NewReader(test.in)))
+
+		if got != test.want {
+			t.Fatalf("TestDetectFormat() failed on %q, got: %q", test.in, got)
+		}
+	}
+}
+
+func TestDetectFormatInteractive(t *testing.T) {
+	for _, test := range []struct {
+		chunks []string
+		want   string
+	}{
+		{[]string{"c comment\n", "p inc", "cnf\n"}, FormatICNF},
+		{[]string{"c comment\n", "1 2 0\n"}, ""},
+	} {
+		in := &chunkReader{chunks: test.chunks}
+		r := bufio.NewReader(in)
+
+		if got := DetectFormat(r); got != test.want || in.blocked {
+			t.Fatalf("TestDetectFormatInteractive() failed on %q, got: %q", test.chunks, got)
+		}
+		// Nothing was consumed.
+		if b, _ := r.Peek(2); string(b) != "c " {
+			t.Fatalf("TestDetectFormatInteractive() failed on %q, got: %q", test.chunks, b)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package encoding
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// ICNFParser parses the incremental CNF format, where clauses are interleaved
+// with assumption lines starting with a, each of which is a query to solve
+// under the assumptions.
+type ICNFParser struct {
+	// Lenient accepts input without a header and a final clause lacking its
+	// terminating 0.
+	Lenient bool
+
+	s      *scanner
+	header bool
+}
+
+// NewICNFParser returns a new strict parser reading from in.
+func NewICNFParser(in io.Reader) *ICNFParser {
+	return &ICNFParser{s: newScanner(in)}
+}
+
+// Next reads up to the next query, returning the clauses preceding it and its
+// assumptions. At the end of the input it returns the remaining clauses, nil
+// assumptions and io.EOF.
+func (p *ICNFParser) Next() ([][]int, []int, error) {
+	sentences := [][]int{}
+	sentence := []int{}
+	var assumps []int
+	s := p.s
+
+	for {
+		tok, err := s.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if s.first && tok[0] == 'c' {
+			if err := s.skipLine(); err != nil && err != io.EOF {
+				return nil, nil, err
+			}
+			continue
+		}
+		if s.first && string(tok) == "p" {
+			if err := p.parseHeader(); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+		if !p.header && !p.Lenient {
+			return nil, nil, s.errorf("expected header, got %q", tok)
+		}
+		if s.first && string(tok) == "a" {
+			if len(sentence) > 0 || assumps != nil {
+				return nil, nil, s.errorf("assumptions inside clause")
+			}
+			assumps = []int{}
+			continue
+		}
+		n, err := s.int("literal")
+		if err != nil {
+			return nil, nil, err
+		}
+		if n != 0 && assumps != nil {
+			assumps = append(assumps, n)
+		} else if n != 0 {
+			sentence = append(sentence, n)
+		} else if assumps != nil {
+			return sentences, assumps, nil
+		} else {
+			sentences = append(sentences, sentence)
+			sentence = []int{}
+		}
+	}
+
+	if len(sentence) > 0 || assumps != nil {
+		if !p.Lenient || assumps != nil {
+			return nil, nil, s.errorf("missing terminating 0")
+		}
+		sentences = append(sentences, sentence)
+	}
+	if !p.header && !p.Lenient {
+		return nil, nil, &ParseError{s.line, s.col, "missing header"}
+	}
+	return sentences, nil, io.EOF
+}
+
+// parseHeader parses the rest of a `p inccnf` line.
+func (p *ICNFParser) parseHeader() error {
+	s := p.s
+
+	if p.header {
+		return s.errorf("duplicate header")
+	}
+	p.header = true
+	line, col := s.line, s.col
+
+	tok, err := s.next()
+	if err == io.EOF || (err == nil && s.first) {
+		return &ParseError{line, col, "missing format in header"}
+	}
+	if err != nil {
+		return err
+	}
+	if string(tok) != "inccnf" {
+		return s.errorf("unsupported format %q", tok)
+	}
+	return nil
+}
+
+// WriteICNF writes clauses and cubes in the incremental CNF format, where each
+// cube is written as an assumption line.
+func WriteICNF(out io.Writer, clauses [][]int, cubes [][]int) error {
+	w := bufio.NewWriter(out)
+
+	fmt.Fprintln(w, "p inccnf")
+
+	for _, clause := range clauses {
+		writeLits(w, "", clause)
+	}
+	for _, cube := range cubes {
+		writeLits(w, "a ", cube)
+	}
+	return w.Flush()
+}
+
+// writeLits writes a zero-terminated line of literals after prefix.
+func writeLits(w *bufio.Writer, prefix string, lits []int) {
+	w.WriteString(prefix)
+
+	for _, p := range lits {
+		fmt.Fprintf(w, "%d ", p)
+	}
+	w.WriteString("0\n")
+}
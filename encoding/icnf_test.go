@@ -0,0 +1,90 @@
+package encoding
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// icnfQueries parses all of the input, returning its clauses and assumptions
+// in the order read.
+func icnfQueries(p *ICNFParser) (string, error) {
+	out := []string{}
+
+	for {
+		clauses, assumps, err := p.Next()
+		if err != nil && err != io.EOF {
+			return "", err
+		}
+		out = append(out, fmt.Sprint(clauses))
+
+		if err == io.EOF {
+			return strings.Join(out, " "), nil
+		}
+		out = append(out, fmt.Sprintf("a%v", assumps))
+	}
+}
+
+func TestICNFParser(t *testing.T) {
+	for _, test := range []struct {
+		in      string
+		lenient bool
+		want    string
+		err     string
+	}{
+		{"p inccnf\n1 2 0\na 1 0\n-1\n0\na -2 0\n", false, "[[1 2]] a[1] [[-1]] a[-2] []", ""},
+		{"c comment\np inccnf\na 0\n", false, "[] a[] []", ""},
+		{"p inccnf\n1 0\n", false, "[[1]]", ""},
+
+		{"1 0\n", false, "", "1:1: expected header, got \"1\""},
+		{"", false, "", "1:1: missing header"},
+		{"p inccnf\n1\na 2 0\n", false, "", "3:1: assumptions inside clause"},
+		{"p inccnf\na 1\na 2 0\n", false, "", "3:1: assumptions inside clause"},
+		{"p inccnf\na 1\n", false, "", "2:3: missing terminating 0"},
+		{"p inccnf\n1 2\n", false, "", "2:3: missing terminating 0"},
+		{"p inccnf\na 1\n", true, "", "2:3: missing terminating 0"},
+		{"p cnf\n", false, "", "1:3: unsupported format \"cnf\""},
+		{"p\n1 0\n", false, "", "1:2: missing format in header"},
+		{"p inccnf\np inccnf\n", false, "", "2:1: duplicate header"},
+		{"p inccnf\n1 0 a 2 0\n", false, "", "2:5: invalid literal \"a\""},
+		{"p inccnf\n1 b 0\n", false, "", "2:3: invalid literal \"b\""},
+
+		{"1 0\na 1 0\n", true, "[[1]] a[1] []", ""},
+		{"p inccnf\n1 2", true, "[[1 2]]", ""},
+	} {
+		p := NewICNFParser(strings.NewReader(test.in))
+		p.Lenient = test.lenient
+
+		got, err := icnfQueries(p)
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Fatalf("TestICNFParser() failed on %q, got: %v", test.in, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("TestICNFParser() failed on %q, got: %v", test.in, err)
+		}
+		if got != test.want {
+			t.Fatalf("TestICNFParser() failed on %q, got: %v", test.in, got)
+		}
+	}
+}
+
+func TestWriteICNF(t *testing.T) {
+	buf := bytes.Buffer{}
+	want := "p inccnf\n1 -2 0\n3 0\na -1 0\na 0\n"
+
+	if err := WriteICNF(&buf, [][]int{{1, -2}, {3}}, [][]int{{-1}, {}}); err != nil {
+		t.Fatalf("TestWriteICNF() failed, got: %v", err)
+	}
+	if buf.String() != want {
+		t.Fatalf("TestWriteICNF() failed, got: %q", buf.String())
+	}
+	got, err := icnfQueries(NewICNFParser(&buf))
+	if err != nil || got != "[[1 -2] [3]] a[-1] [] a[] []" {
+		t.Fatalf("TestWriteICNF() failed, got: %v %v", got, err)
+	}
+}
@@ -0,0 +1,513 @@
+package encoding
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// Parameters of the LZMA model.
+const (
+	lzmaStates      = 12
+	lzmaPosBitsMax  = 4
+	lzmaLenStates   = 4
+	lzmaEndPosModel = 14
+	lzmaProbInit    = 1 << 10
+)
+
+// lzmaDict is the sliding window of decompressed data matches are copied
+// from. It grows up to its size as data is written.
+type lzmaDict struct {
+	buf  []byte
+	size int
+	// pos is the position of the next byte in buf.
+	pos int
+	// full is the number of bytes that can be referenced.
+	full int
+	// total is the number of bytes written since the last reset.
+	total uint32
+}
+
+// reset empties the dictionary and sets its size.
+func (d *lzmaDict) reset(size int) {
+	d.buf = d.buf[:0]
+	d.size = size
+	d.pos = 0
+	d.full = 0
+	d.total = 0
+}
+
+// put writes b to the dictionary.
+func (d *lzmaDict) put(b byte) {
+	if d.pos < len(d.buf) {
+		d.buf[d.pos] = b
+	} else {
+		d.buf = append(d.buf, b)
+	}
+	if d.pos++; d.pos == d.size {
+		d.pos = 0
+	}
+	if d.full < d.size {
+		d.full++
+	}
+	d.total++
+}
+
+// get returns the byte written dist bytes ago, where dist starts at 1.
+func (d *lzmaDict) get(dist int) byte {
+	i := d.pos - dist
+	if i < 0 {
+		i += len(d.buf)
+	}
+	return d.buf[i]
+}
+
+// rangeDecoder decodes bits from a chunk of LZMA data.
+type rangeDecoder struct {
+	in   []byte
+	pos  int
+	rng  uint32
+	code uint32
+	// overrun is whether bits were decoded past the end of in.
+	overrun bool
+}
+
+// init starts decoding in.
+func (rc *rangeDecoder) init(in []byte) error {
+	if len(in) < 5 || in[0] != 0 {
+		return errXZCorrupt
+	}
+	rc.in = in
+	rc.pos = 5
+	rc.rng = 0xffffffff
+	rc.code = binary.BigEndian.Uint32(in[1:5])
+	rc.overrun = false
+
+	return nil
+}
+
+// finished returns whether the input was decoded exactly.
+func (rc *rangeDecoder) finished() bool {
+	rc.normalize()
+	return !rc.overrun && rc.pos == len(rc.in) && rc.code == 0
+}
+
+// normalize shifts in the next byte when the range gets too small.
+func (rc *rangeDecoder) normalize() {
+	if rc.rng >= 1<<24 {
+		return
+	}
+	rc.rng <<= 8
+	rc.code <<= 8
+
+	if rc.pos < len(rc.in) {
+		rc.code |= uint32(rc.in[rc.pos])
+		rc.pos++
+	} else {
+		rc.overrun = true
+	}
+}
+
+// bit decodes a bit with the probability p of being 0, adapting p.
+func (rc *rangeDecoder) bit(p *uint16) uint32 {
+	rc.normalize()
+	bound := (rc.rng >> 11) * uint32(*p)
+
+	if rc.code < bound {
+		rc.rng = bound
+		*p += (1<<11 - *p) >> 5
+		return 0
+	}
+	rc.rng -= bound
+	rc.code -= bound
+	*p -= *p >> 5
+	return 1
+}
+
+// direct decodes n bits with equal probabilities, most significant first.
+func (rc *rangeDecoder) direct(n uint32) uint32 {
+	x := uint32(0)
+
+	for ; n > 0; n-- {
+		rc.normalize()
+		rc.rng >>= 1
+		x <<= 1
+
+		if rc.code >= rc.rng {
+			rc.code -= rc.rng
+			x |= 1
+		}
+	}
+	return x
+}
+
+// tree decodes n bits most significant first with a tree of probabilities.
+func (rc *rangeDecoder) tree(probs []uint16, n uint32) uint32 {
+	m := uint32(1)
+
+	for i := uint32(0); i < n; i++ {
+		m = m<<1 | rc.bit(&probs[m])
+	}
+	return m - 1<<n
+}
+
+// reverseTree decodes n bits least significant first with a tree of
+// probabilities.
+func (rc *rangeDecoder) reverseTree(probs []uint16, n uint32) uint32 {
+	m, x := uint32(1), uint32(0)
+
+	for i := uint32(0); i < n; i++ {
+		b := rc.bit(&probs[m])
+		m = m<<1 | b
+		x |= b << i
+	}
+	return x
+}
+
+// lzmaLenDecoder decodes match lengths, less the minimum length of 2.
+type lzmaLenDecoder struct {
+	choice  uint16
+	choice2 uint16
+	low     [1 << lzmaPosBitsMax][1 << 3]uint16
+	mid     [1 << lzmaPosBitsMax][1 << 3]uint16
+	high    [1 << 8]uint16
+}
+
+// reset resets the probabilities.
+func (l *lzmaLenDecoder) reset() {
+	l.choice, l.choice2 = lzmaProbInit, lzmaProbInit
+
+	for i := range l.low {
+		resetProbs(l.low[i][:])
+		resetProbs(l.mid[i][:])
+	}
+	resetProbs(l.high[:])
+}
+
+// decode decodes a length in the given position state.
+func (l *lzmaLenDecoder) decode(rc *rangeDecoder, posState uint32) uint32 {
+	if rc.bit(&l.choice) == 0 {
+		return rc.tree(l.low[posState][:], 3)
+	}
+	if rc.bit(&l.choice2) == 0 {
+		return 8 + rc.tree(l.mid[posState][:], 3)
+	}
+	return 16 + rc.tree(l.high[:], 8)
+}
+
+// lzmaDecoder decodes LZMA chunks, keeping its state between them.
+type lzmaDecoder struct {
+	lc, lp, pb uint32
+	rc         rangeDecoder
+	state      uint32
+	// rep are the distances of the last four matches, less 1.
+	rep        [4]uint32
+	literal    []uint16
+	isMatch    [lzmaStates << lzmaPosBitsMax]uint16
+	isRep      [lzmaStates]uint16
+	isRepG0    [lzmaStates]uint16
+	isRepG1    [lzmaStates]uint16
+	isRepG2    [lzmaStates]uint16
+	isRep0Long [lzmaStates << lzmaPosBitsMax]uint16
+	posSlot    [lzmaLenStates][1 << 6]uint16
+	posSpecial [1 + 1<<(lzmaEndPosModel/2) - lzmaEndPosModel]uint16
+	align      [1 << 4]uint16
+	matchLen   lzmaLenDecoder
+	repLen     lzmaLenDecoder
+}
+
+// resetProbs sets probs to even probabilities.
+func resetProbs(probs []uint16) {
+	for i := range probs {
+		probs[i] = lzmaProbInit
+	}
+}
+
+// setProps sets the literal context, literal position and position bits
+// encoded in b.
+func (d *lzmaDecoder) setProps(b byte) error {
+	if b >= 9*5*5 {
+		return errXZCorrupt
+	}
+	d.lc, d.lp, d.pb = uint32(b%9), uint32(b/9%5), uint32(b/45)
+
+	// LZMA2 limits the literal coder's size.
+	if d.lc+d.lp > 4 {
+		return errXZCorrupt
+	}
+	n := 0x300 << (d.lc + d.lp)
+	if cap(d.literal) < n {
+		d.literal = make([]uint16, n)
+	}
+	d.literal = d.literal[:n]
+
+	return nil
+}
+
+// reset resets the state and the probabilities.
+func (d *lzmaDecoder) reset() {
+	d.state = 0
+	d.rep = [4]uint32{}
+
+	resetProbs(d.literal)
+	resetProbs(d.isMatch[:])
+	resetProbs(d.isRep[:])
+	resetProbs(d.isRepG0[:])
+	resetProbs(d.isRepG1[:])
+	resetProbs(d.isRepG2[:])
+	resetProbs(d.isRep0Long[:])
+	for i := range d.posSlot {
+		resetProbs(d.posSlot[i][:])
+	}
+	resetProbs(d.posSpecial[:])
+	resetProbs(d.align[:])
+	d.matchLen.reset()
+	d.repLen.reset()
+}
+
+// decode decodes n bytes packed in in through dict, appending them to out.
+func (d *lzmaDecoder) decode(in []byte, n int, dict *lzmaDict, out []byte) ([]byte, error) {
+	rc := &d.rc
+	if err := rc.init(in); err != nil {
+		return out, err
+	}
+	pbMask := uint32(1)<<d.pb - 1
+
+	for end := len(out) + n; len(out) < end; {
+		posState := dict.total & pbMask
+		i := d.state<<lzmaPosBitsMax | posState
+
+		if rc.bit(&d.isMatch[i]) == 0 {
+			b := d.decodeLiteral(dict)
+			dict.put(b)
+			out = append(out, b)
+
+			switch {
+			case d.state < 4:
+				d.state = 0
+			case d.state < 10:
+				d.state -= 3
+			default:
+				d.state -= 6
+			}
+			continue
+		}
+		length := uint32(0)
+
+		if rc.bit(&d.isRep[d.state]) == 0 {
+			length = d.matchLen.decode(rc, posState)
+			d.rep[3], d.rep[2], d.rep[1] = d.rep[2], d.rep[1], d.rep[0]
+			d.rep[0] = d.decodeDistance(length)
+			d.state = nextState(d.state, 7, 10)
+		} else {
+			if rc.bit(&d.isRepG0[d.state]) == 0 {
+				// A short rep copies a single byte from the last distance.
+				if rc.bit(&d.isRep0Long[i]) == 0 {
+					if int(d.rep[0]) >= dict.full {
+						return out, errXZCorrupt
+					}
+					b := dict.get(int(d.rep[0]) + 1)
+					dict.put(b)
+					out = append(out, b)
+					d.state = nextState(d.state, 9, 11)
+					continue
+				}
+			} else {
+				dist := uint32(0)
+
+				if rc.bit(&d.isRepG1[d.state]) == 0 {
+					dist = d.rep[1]
+				} else {
+					if rc.bit(&d.isRepG2[d.state]) == 0 {
+						dist = d.rep[2]
+					} else {
+						dist = d.rep[3]
+						d.rep[3] = d.rep[2]
+					}
+					d.rep[2] = d.rep[1]
+				}
+				d.rep[1] = d.rep[0]
+				d.rep[0] = dist
+			}
+			length = d.repLen.decode(rc, posState)
+			d.state = nextState(d.state, 8, 11)
+		}
+		// Matches may neither reach before the dictionary nor span chunks.
+		if int(d.rep[0]) >= dict.full || int(length)+2 > end-len(out) {
+			return out, errXZCorrupt
+		}
+		for k := uint32(0); k < length+2; k++ {
+			b := dict.get(int(d.rep[0]) + 1)
+			dict.put(b)
+			out = append(out, b)
+		}
+	}
+	if !rc.finished() {
+		return out, errXZCorrupt
+	}
+	return out, nil
+}
+
+// nextState returns the state following a match, given the states to follow
+// literal and match states.
+func nextState(state uint32, afterLiteral uint32, afterMatch uint32) uint32 {
+	if state < 7 {
+		return afterLiteral
+	}
+	return afterMatch
+}
+
+// decodeLiteral decodes a literal byte, in the context of the last byte and,
+// following a match, of the byte at the last distance.
+func (d *lzmaDecoder) decodeLiteral(dict *lzmaDict) byte {
+	rc := &d.rc
+	prev := uint32(0)
+	if dict.full > 0 {
+		prev = uint32(dict.get(1))
+	}
+	lit := (dict.total&(1<<d.lp-1))<<d.lc | prev>>(8-d.lc)
+	probs := d.literal[0x300*lit : 0x300*(lit+1)]
+	sym := uint32(1)
+
+	if d.state >= 7 {
+		match := uint32(dict.get(int(d.rep[0]) + 1))
+
+		for sym < 0x100 {
+			matchBit := match >> 7 & 1
+			match <<= 1
+			b := rc.bit(&probs[(1+matchBit)<<8|sym])
+			sym = sym<<1 | b
+
+			if b != matchBit {
+				break
+			}
+		}
+	}
+	for sym < 0x100 {
+		sym = sym<<1 | rc.bit(&probs[sym])
+	}
+	return byte(sym)
+}
+
+// decodeDistance decodes the distance of a match of the given length, less
+// 1.
+func (d *lzmaDecoder) decodeDistance(length uint32) uint32 {
+	rc := &d.rc
+	if length >= lzmaLenStates {
+		length = lzmaLenStates - 1
+	}
+	slot := rc.tree(d.posSlot[length][:], 6)
+	if slot < 4 {
+		return slot
+	}
+	n := slot>>1 - 1
+	dist := (2 | slot&1) << n
+
+	if slot < lzmaEndPosModel {
+		return dist + rc.reverseTree(d.posSpecial[dist-slot:], n)
+	}
+	dist += rc.direct(n-4) << 4
+	return dist + rc.reverseTree(d.align[:], 4)
+}
+
+// lzma2Decoder decodes LZMA2 data, which is a sequence of chunks that are
+// either LZMA compressed or stored.
+type lzma2Decoder struct {
+	lzma lzmaDecoder
+	dict lzmaDict
+	// needDict and needProps are whether the next chunk must reset the
+	// dictionary and set new properties.
+	needDict  bool
+	needProps bool
+	header    [5]byte
+	in        []byte
+	out       []byte
+}
+
+// reset prepares for new LZMA2 data with the given dictionary size.
+func (d *lzma2Decoder) reset(dictSize int) {
+	d.dict.reset(dictSize)
+	d.needDict = true
+	d.needProps = true
+}
+
+// next decodes the next chunk read from r, returning io.EOF at the end of the
+// LZMA2 data. The returned data is valid until the next call.
+func (d *lzma2Decoder) next(r io.Reader) ([]byte, error) {
+	control := d.header[:1]
+	if _, err := io.ReadFull(r, control); err != nil {
+		return nil, unexpected(err)
+	}
+	c := control[0]
+
+	switch {
+	case c == 0x00:
+		return nil, io.EOF
+	case c == 0x01 || c == 0x02:
+		if c == 0x01 {
+			d.dict.reset(d.dict.size)
+			d.needDict = false
+		} else if d.needDict {
+			return nil, errXZCorrupt
+		}
+		size := d.header[:2]
+		if _, err := io.ReadFull(r, size); err != nil {
+			return nil, unexpected(err)
+		}
+		d.out = grow(d.out, int(binary.BigEndian.Uint16(size))+1)
+
+		if _, err := io.ReadFull(r, d.out); err != nil {
+			return nil, unexpected(err)
+		}
+		for _, b := range d.out {
+			d.dict.put(b)
+		}
+		return d.out, nil
+	case c >= 0x80:
+		reset := c >> 5 & 3
+		header := d.header[:4]
+		if reset >= 2 {
+			header = d.header[:5]
+		}
+		if _, err := io.ReadFull(r, header); err != nil {
+			return nil, unexpected(err)
+		}
+		unpacked := int(c&0x1f)<<16 + int(binary.BigEndian.Uint16(header)) + 1
+		packed := int(binary.BigEndian.Uint16(header[2:])) + 1
+
+		if reset == 3 {
+			d.dict.reset(d.dict.size)
+			d.needDict = false
+		} else if d.needDict {
+			return nil, errXZCorrupt
+		}
+		if reset >= 2 {
+			if err := d.lzma.setProps(header[4]); err != nil {
+				return nil, err
+			}
+			d.needProps = false
+		} else if d.needProps {
+			return nil, errXZCorrupt
+		}
+		if reset >= 1 {
+			d.lzma.reset()
+		}
+		d.in = grow(d.in, packed)
+
+		if _, err := io.ReadFull(r, d.in); err != nil {
+			return nil, unexpected(err)
+		}
+		out, err := d.lzma.decode(d.in, unpacked, &d.dict, d.out[:0])
+		d.out = out
+
+		return out, err
+	}
+	return nil, errXZCorrupt
+}
+
+// grow returns buf resized to n bytes, reusing its storage when possible.
+func grow(buf []byte, n int) []byte {
+	if cap(buf) < n {
+		return make([]byte, n)
+	}
+	return buf[:n]
+}
@@ -0,0 +1,406 @@
+package encoding
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"hash/crc64"
+	"io"
+)
+
+// errXZCorrupt is returned for input that isn't valid xz.
+var errXZCorrupt = errors.New("xz: corrupt input")
+
+// Integrity checks of xz streams.
+const (
+	xzCheckNone   = 0x00
+	xzCheckCRC32  = 0x01
+	xzCheckCRC64  = 0x04
+	xzCheckSHA256 = 0x0a
+)
+
+// xzFilterLZMA2 is the ID of the LZMA2 filter, the only supported filter.
+const xzFilterLZMA2 = 0x21
+
+// xzFooterMagic are the magic bytes ending a stream.
+var xzFooterMagic = []byte("YZ")
+
+var crc64Table = crc64.MakeTable(crc64.ECMA)
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r *bufio.Reader
+	n int64
+}
+
+// Read implements the io.Reader interface.
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// ReadByte implements the io.ByteReader interface.
+func (c *countingReader) ReadByte() (byte, error) {
+	b, err := c.r.ReadByte()
+	if err == nil {
+		c.n++
+	}
+	return b, err
+}
+
+// xzReader decompresses concatenated xz streams, whose blocks are compressed
+// by LZMA2 alone, which is how xz compresses by default.
+type xzReader struct {
+	r   *countingReader
+	dec lzma2Decoder
+	// streams is the number of streams started.
+	streams int
+	// flags are the flags of the current stream, or nil between streams.
+	flags []byte
+	check hash.Hash
+	// block is whether a block is being decompressed, which started at
+	// blockStart with a header of headerSize bytes.
+	block      bool
+	blockStart int64
+	headerSize int64
+	// sizes are the compressed and uncompressed sizes declared by the block
+	// header, or -1, and size is the uncompressed size so far.
+	sizes [2]int64
+	size  int64
+	// records are the unpadded and uncompressed sizes of the blocks of the
+	// current stream, as listed by its index.
+	records [][2]int64
+	out     []byte
+	err     error
+}
+
+// newXZReader returns a reader decompressing the xz streams read from r.
+func newXZReader(r *bufio.Reader) (io.Reader, error) {
+	z := &xzReader{r: &countingReader{r: r}}
+
+	if err := z.readStreamHeader(); err != nil {
+		return nil, err
+	}
+	return z, nil
+}
+
+// unexpected returns err, or io.ErrUnexpectedEOF when the input ended.
+func unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// Read implements the io.Reader interface.
+func (z *xzReader) Read(p []byte) (int, error) {
+	for len(z.out) == 0 && z.err == nil {
+		z.err = z.fill()
+	}
+	n := copy(p, z.out)
+	z.out = z.out[n:]
+
+	if n > 0 {
+		return n, nil
+	}
+	return 0, z.err
+}
+
+// fill decompresses the next chunk of the current block into out, starting
+// the next block or stream as needed.
+func (z *xzReader) fill() error {
+	if !z.block {
+		return z.nextBlock()
+	}
+	out, err := z.dec.next(z.r)
+	if err == io.EOF {
+		return z.endBlock()
+	}
+	if err != nil {
+		return err
+	}
+	z.check.Write(out)
+	z.size += int64(len(out))
+	z.out = out
+
+	return nil
+}
+
+// readStreamHeader reads the header of the next stream, skipping the padding
+// allowed between streams. It returns io.EOF at the end of the input.
+func (z *xzReader) readStreamHeader() error {
+	header := make([]byte, 12)
+
+	for {
+		if _, err := io.ReadFull(z.r, header[:4]); err == io.EOF && z.streams > 0 {
+			return io.EOF
+		} else if err != nil {
+			return unexpected(err)
+		}
+		if z.streams == 0 || !bytes.Equal(header[:4], []byte{0, 0, 0, 0}) {
+			break
+		}
+	}
+	if _, err := io.ReadFull(z.r, header[4:]); err != nil {
+		return unexpected(err)
+	}
+	flags := header[6:8]
+
+	if !bytes.Equal(header[:6], xzMagic) ||
+		crc32.ChecksumIEEE(flags) != binary.LittleEndian.Uint32(header[8:]) ||
+		flags[0] != 0 || flags[1]&0xf0 != 0 {
+		return errXZCorrupt
+	}
+	switch flags[1] {
+	case xzCheckNone:
+		z.check = nopHash{}
+	case xzCheckCRC32:
+		z.check = crc32.NewIEEE()
+	case xzCheckCRC64:
+		z.check = crc64.New(crc64Table)
+	case xzCheckSHA256:
+		z.check = sha256.New()
+	default:
+		return fmt.Errorf("xz: unsupported check type %d", flags[1])
+	}
+	z.streams++
+	z.flags = append([]byte{}, flags...)
+	z.records = z.records[:0]
+
+	return nil
+}
+
+// nextBlock starts the next block, reading the index and footer of streams
+// that end first. It returns io.EOF at the end of the input.
+func (z *xzReader) nextBlock() error {
+	for {
+		if z.flags == nil {
+			if err := z.readStreamHeader(); err != nil {
+				return err
+			}
+		}
+		size, err := z.r.ReadByte()
+		if err != nil {
+			return unexpected(err)
+		}
+		// An index indicator takes the place of a block header size.
+		if size != 0 {
+			return z.readBlockHeader(int64(size)*4 + 4)
+		}
+		if err := z.readIndex(); err != nil {
+			return err
+		}
+		z.flags = nil
+	}
+}
+
+// readBlockHeader reads the rest of a block header of the given size.
+func (z *xzReader) readBlockHeader(size int64) error {
+	header := make([]byte, size)
+	header[0] = byte(size/4 - 1)
+
+	if _, err := io.ReadFull(z.r, header[1:]); err != nil {
+		return unexpected(err)
+	}
+	if crc32.ChecksumIEEE(header[:size-4]) != binary.LittleEndian.Uint32(header[size-4:]) {
+		return errXZCorrupt
+	}
+	flags := header[1]
+	r := bytes.NewReader(header[2 : size-4])
+
+	if flags&0x3c != 0 {
+		return errXZCorrupt
+	}
+	if flags&0x03 != 0 {
+		return fmt.Errorf("xz: unsupported filter chain of %d filters", flags&0x03+1)
+	}
+	z.sizes = [2]int64{-1, -1}
+
+	for i, bit := range []byte{0x40, 0x80} {
+		if flags&bit == 0 {
+			continue
+		}
+		n, err := readUvarint(r)
+		if err != nil {
+			return err
+		}
+		z.sizes[i] = int64(n)
+	}
+	id, err := readUvarint(r)
+	if err != nil {
+		return err
+	}
+	if id != xzFilterLZMA2 {
+		return fmt.Errorf("xz: unsupported filter %#x", id)
+	}
+	if n, err := readUvarint(r); err != nil {
+		return err
+	} else if n != 1 {
+		return errXZCorrupt
+	}
+	props, err := r.ReadByte()
+	if err != nil {
+		return errXZCorrupt
+	}
+	// The rest of the header is padding.
+	for r.Len() > 0 {
+		if b, _ := r.ReadByte(); b != 0 {
+			return errXZCorrupt
+		}
+	}
+	if props > 40 {
+		return errXZCorrupt
+	}
+	dictSize := int64(0xffffffff)
+	if props < 40 {
+		dictSize = int64(2|props&1) << (props/2 + 11)
+	}
+	z.dec.reset(int(dictSize))
+	z.check.Reset()
+	z.block = true
+	z.blockStart = z.r.n - size
+	z.headerSize = size
+	z.size = 0
+
+	return nil
+}
+
+// endBlock reads the padding and check ending the current block.
+func (z *xzReader) endBlock() error {
+	compressed := z.r.n - z.blockStart - z.headerSize
+
+	for n := z.r.n - z.blockStart; n%4 != 0; n++ {
+		if b, err := z.r.ReadByte(); err != nil {
+			return unexpected(err)
+		} else if b != 0 {
+			return errXZCorrupt
+		}
+	}
+	want := z.check.Sum(nil)
+	got := make([]byte, len(want))
+
+	if _, err := io.ReadFull(z.r, got); err != nil {
+		return unexpected(err)
+	}
+	// CRCs are stored little endian.
+	if z.flags[1] == xzCheckCRC32 || z.flags[1] == xzCheckCRC64 {
+		for i, j := 0, len(got)-1; i < j; i, j = i+1, j-1 {
+			got[i], got[j] = got[j], got[i]
+		}
+	}
+	if !bytes.Equal(got, want) {
+		return errors.New("xz: checksum mismatch")
+	}
+	if (z.sizes[0] != -1 && z.sizes[0] != compressed) ||
+		(z.sizes[1] != -1 && z.sizes[1] != z.size) {
+		return errXZCorrupt
+	}
+	z.records = append(z.records, [2]int64{z.headerSize + compressed + int64(len(got)), z.size})
+	z.block = false
+
+	return nil
+}
+
+// readIndex reads the rest of the index of the current stream, whose
+// indicator was read, and the stream footer, validating them against the
+// blocks read.
+func (z *xzReader) readIndex() error {
+	start := z.r.n - 1
+	h := crc32.NewIEEE()
+	h.Write([]byte{0})
+	r := &hashingReader{z.r, h}
+
+	n, err := readUvarint(r)
+	if err != nil {
+		return err
+	}
+	if n != uint64(len(z.records)) {
+		return errXZCorrupt
+	}
+	for _, record := range z.records {
+		for _, size := range record {
+			if n, err := readUvarint(r); err != nil {
+				return err
+			} else if n != uint64(size) {
+				return errXZCorrupt
+			}
+		}
+	}
+	for n := z.r.n - start; n%4 != 0; n++ {
+		if b, err := r.ReadByte(); err != nil {
+			return unexpected(err)
+		} else if b != 0 {
+			return errXZCorrupt
+		}
+	}
+	sum := h.Sum32()
+	footer := make([]byte, 16)
+
+	if _, err := io.ReadFull(z.r, footer); err != nil {
+		return unexpected(err)
+	}
+	if binary.LittleEndian.Uint32(footer) != sum {
+		return errXZCorrupt
+	}
+	footer = footer[4:]
+	size := z.r.n - 12 - start
+
+	if crc32.ChecksumIEEE(footer[4:10]) != binary.LittleEndian.Uint32(footer) ||
+		int64(binary.LittleEndian.Uint32(footer[4:]))*4+4 != size ||
+		!bytes.Equal(footer[8:10], z.flags) || !bytes.Equal(footer[10:], xzFooterMagic) {
+		return errXZCorrupt
+	}
+	return nil
+}
+
+// readUvarint reads a variable length integer of up to 63 bits.
+func readUvarint(r io.ByteReader) (uint64, error) {
+	x := uint64(0)
+
+	for i := 0; i < 9; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, errXZCorrupt
+		}
+		// Integers must be encoded in as few bytes as possible.
+		if i > 0 && b == 0 {
+			return 0, errXZCorrupt
+		}
+		x |= uint64(b&0x7f) << (7 * i)
+
+		if b&0x80 == 0 {
+			return x, nil
+		}
+	}
+	return 0, errXZCorrupt
+}
+
+// hashingReader hashes the bytes read through it.
+type hashingReader struct {
+	r *countingReader
+	h hash.Hash
+}
+
+// ReadByte implements the io.ByteReader interface.
+func (h *hashingReader) ReadByte() (byte, error) {
+	b, err := h.r.ReadByte()
+	if err == nil {
+		h.h.Write([]byte{b})
+	}
+	return b, err
+}
+
+// nopHash is the check of streams without an integrity check.
+type nopHash struct{}
+
+func (nopHash) Write(p []byte) (int, error) { return len(p), nil }
+func (nopHash) Sum(b []byte) []byte         { return b }
+func (nopHash) Reset()                      {}
+func (nopHash) Size() int                   { return 0 }
+func (nopHash) BlockSize() int              { return 1 }
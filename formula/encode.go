@@ -0,0 +1,96 @@
+package formula
+
+// Assert adds f to the solver's constraints. It returns false when the solver
+// is then trivially unsatisfiable.
+func (b *Builder) Assert(f Formula) bool {
+	return b.sat.AddClause([]int{b.encode(f, true)})
+}
+
+// Lit returns the solver literal equivalent to f, for use as an assumption.
+func (b *Builder) Lit(f Formula) int {
+	return b.exact(f)
+}
+
+// Model returns the values of the named variables in the solver's model.
+// Variables that don't occur in the asserted formulas are false.
+func (b *Builder) Model() map[string]bool {
+	values := map[int]bool{}
+	model := map[string]bool{}
+
+	for _, p := range b.sat.Answer() {
+		values[p] = true
+	}
+	for name, f := range b.vars {
+		p := b.lits[f.node()]
+		model[name] = p != 0 && values[p]
+	}
+	return model
+}
+
+// encode returns the solver literal of f, encoding it for occurrences in the
+// given polarity: when positive the literal implies f, otherwise f implies the
+// literal.
+func (b *Builder) encode(f Formula, positive bool) int {
+	i := f.node()
+	polarity := positive != f.negated()
+	flag := uint8(polarityNeg)
+	if polarity {
+		flag = polarityPos
+	}
+
+	if b.lits[i] == 0 {
+		b.nVars++
+		b.lits[i] = b.nVars
+
+		if i == 0 {
+			b.sat.AddClause([]int{-b.lits[i]})
+		}
+	}
+	x := b.lits[i]
+
+	if b.encoded[i]&flag == 0 {
+		b.encoded[i] |= flag
+		n := b.nodes[i]
+
+		switch {
+		case n.kind == kindAnd && polarity:
+			b.sat.AddClause([]int{-x, b.encode(n.a, true)})
+			b.sat.AddClause([]int{-x, b.encode(n.b, true)})
+		case n.kind == kindAnd:
+			b.sat.AddClause([]int{x, -b.encode(n.a, false), -b.encode(n.b, false)})
+		case n.kind == kindXor:
+			a, c := b.exact(n.a), b.exact(n.b)
+
+			if polarity {
+				b.sat.AddClause([]int{-x, a, c})
+				b.sat.AddClause([]int{-x, -a, -c})
+			} else {
+				b.sat.AddClause([]int{x, -a, c})
+				b.sat.AddClause([]int{x, a, -c})
+			}
+		case n.kind == kindIte:
+			c := b.exact(n.a)
+			t, e := b.encode(n.b, polarity), b.encode(n.c, polarity)
+
+			if polarity {
+				b.sat.AddClause([]int{-x, -c, t})
+				b.sat.AddClause([]int{-x, c, e})
+			} else {
+				b.sat.AddClause([]int{x, -c, -t})
+				b.sat.AddClause([]int{x, c, -e})
+			}
+		}
+	}
+	if f.negated() {
+		return -x
+	}
+	return x
+}
+
+// exact returns the solver literal of f encoded in both polarities, for
+// occurrences that must be equivalent to f, such as the inputs of exclusive
+// disjunctions and the conditions of if-then-else gates.
+func (b *Builder) exact(f Formula) int {
+	b.encode(f, false)
+	return b.encode(f, true)
+}
@@ -0,0 +1,258 @@
+// Package formula builds propositional formulas over named variables and
+// encodes them into a solver.
+package formula
+
+import (
+	"github.com/ericr/saturday/solver"
+)
+
+// Formula is a formula of a Builder: twice the index of its node, plus one
+// when negated. The formulas 0 and 1 are the constants false and true.
+type Formula uint32
+
+const (
+	False = Formula(0)
+	True  = Formula(1)
+)
+
+// Not negates a formula.
+func (f Formula) Not() Formula {
+	return f ^ 1
+}
+
+// node returns the index of the formula's node.
+func (f Formula) node() int {
+	return int(f >> 1)
+}
+
+// negated returns true if the formula is a negated node.
+func (f Formula) negated() bool {
+	return f&1 == 1
+}
+
+// kind is the kind of a node.
+type kind uint8
+
+const (
+	kindConst kind = iota
+	kindVar
+	kindAnd
+	kindXor
+	kindIte
+)
+
+// node is a variable or a gate over up to three formulas.
+type node struct {
+	kind    kind
+	a, b, c Formula
+}
+
+// Polarities in which nodes are encoded.
+const (
+	polarityPos = 1 << iota
+	polarityNeg
+)
+
+// Builder builds formulas and asserts them in a solver. Nodes are hashed
+// structurally, so that building the same gate twice returns the same formula,
+// and simplified when built.
+//
+// Formulas are encoded into CNF by Tseitin transformation with polarity
+// optimization, as described by Plaisted and Greenbaum: a gate is only
+// constrained in the directions it's used in. The builder numbers the solver's
+// variables, which shouldn't be used by other clauses.
+type Builder struct {
+	sat   *solver.Solver
+	nodes []node
+	// hash contains the index of each gate node.
+	hash map[node]int
+	// vars contains the formula of each named variable, and names the name of
+	// each variable node.
+	vars  map[string]Formula
+	names map[int]string
+	// lits contains the CNF variable of each node, 0 when not encoded.
+	lits []int
+	// encoded contains the polarities each node was encoded in.
+	encoded []uint8
+	nVars   int
+}
+
+// New returns a builder asserting formulas in s.
+func New(s *solver.Solver) *Builder {
+	return &Builder{
+		sat:     s,
+		nodes:   []node{{kind: kindConst}},
+		hash:    map[node]int{},
+		vars:    map[string]Formula{},
+		names:   map[int]string{},
+		lits:    []int{0},
+		encoded: []uint8{0},
+	}
+}
+
+// Var returns the variable named name, creating it when needed.
+func (b *Builder) Var(name string) Formula {
+	if f, ok := b.vars[name]; ok {
+		return f
+	}
+	f := b.newNode(node{kind: kindVar})
+	b.vars[name] = f
+	b.names[f.node()] = name
+
+	return f
+}
+
+// Not returns the negation of f.
+func (b *Builder) Not(f Formula) Formula {
+	return f.Not()
+}
+
+// And returns the conjunction of fs, which is true when fs is empty.
+func (b *Builder) And(fs ...Formula) Formula {
+	f := True
+
+	for _, g := range fs {
+		f = b.and(f, g)
+	}
+	return f
+}
+
+// Or returns the disjunction of fs, which is false when fs is empty.
+func (b *Builder) Or(fs ...Formula) Formula {
+	f := False
+
+	for _, g := range fs {
+		f = b.and(f.Not(), g.Not()).Not()
+	}
+	return f
+}
+
+// Implies returns the implication of g by f.
+func (b *Builder) Implies(f Formula, g Formula) Formula {
+	return b.Or(f.Not(), g)
+}
+
+// Iff returns the equivalence of f and g.
+func (b *Builder) Iff(f Formula, g Formula) Formula {
+	return b.Xor(f, g).Not()
+}
+
+// Xor returns the exclusive disjunction of f and g.
+func (b *Builder) Xor(f Formula, g Formula) Formula {
+	// Negations are moved out of the gate.
+	neg := f.negated() != g.negated()
+	f, g = f&^1, g&^1
+
+	if f > g {
+		f, g = g, f
+	}
+	x := False
+
+	switch {
+	case f == g:
+	case f == False:
+		x = g
+	default:
+		x = b.gate(node{kind: kindXor, a: f, b: g})
+	}
+	if neg {
+		return x.Not()
+	}
+	return x
+}
+
+// Ite returns the formula that is t when c is true and e otherwise.
+func (b *Builder) Ite(c Formula, t Formula, e Formula) Formula {
+	if c.negated() {
+		c, t, e = c.Not(), e, t
+	}
+	switch {
+	case c == False:
+		return e
+	case t == e:
+		return t
+	case t == e.Not():
+		return b.Iff(c, t)
+	case t == True || t == c:
+		return b.Or(c, e)
+	case t == False || t == c.Not():
+		return b.And(c.Not(), e)
+	case e == True || e == c.Not():
+		return b.Or(c.Not(), t)
+	case e == False || e == c:
+		return b.And(c, t)
+	}
+	// The then branch isn't negated, so that negated gates are shared.
+	if t.negated() {
+		return b.gate(node{kind: kindIte, a: c, b: t.Not(), c: e.Not()}).Not()
+	}
+	return b.gate(node{kind: kindIte, a: c, b: t, c: e})
+}
+
+// and returns the conjunction of f and g.
+func (b *Builder) and(f Formula, g Formula) Formula {
+	if f > g {
+		f, g = g, f
+	}
+	switch {
+	case f == False || f == g.Not():
+		return False
+	case f == True || f == g:
+		return g
+	}
+	return b.gate(node{kind: kindAnd, a: f, b: g})
+}
+
+// gate returns the formula of a gate node, reusing a structurally equal one.
+func (b *Builder) gate(n node) Formula {
+	if i, ok := b.hash[n]; ok {
+		return Formula(2 * i)
+	}
+	f := b.newNode(n)
+	b.hash[n] = f.node()
+
+	return f
+}
+
+// newNode adds a node.
+func (b *Builder) newNode(n node) Formula {
+	b.nodes = append(b.nodes, n)
+	b.lits = append(b.lits, 0)
+	b.encoded = append(b.encoded, 0)
+
+	return Formula(2 * (len(b.nodes) - 1))
+}
+
+// Eval evaluates f under the values of the named variables, which are false
+// when missing.
+func (b *Builder) Eval(f Formula, values map[string]bool) bool {
+	memo := map[int]bool{}
+	var eval func(f Formula) bool
+
+	eval = func(f Formula) bool {
+		i := f.node()
+		v, ok := memo[i]
+
+		if !ok {
+			n := b.nodes[i]
+
+			switch n.kind {
+			case kindVar:
+				v = values[b.names[i]]
+			case kindAnd:
+				v = eval(n.a) && eval(n.b)
+			case kindXor:
+				v = eval(n.a) != eval(n.b)
+			case kindIte:
+				if eval(n.a) {
+					v = eval(n.b)
+				} else {
+					v = eval(n.c)
+				}
+			}
+			memo[i] = v
+		}
+		return v != f.negated()
+	}
+	return eval(f)
+}
@@ -0,0 +1,129 @@
+package formula
+
+import (
+	"fmt"
+	"github.com/ericr/saturday/config"
+	"github.com/ericr/saturday/solver"
+	"math/rand"
+	"testing"
+)
+
+// randomFormula returns a random formula of the given depth over the
+// variables.
+func randomFormula(b *Builder, r *rand.Rand, vars []Formula, depth int) Formula {
+	if depth == 0 || r.Intn(4) == 0 {
+		switch r.Intn(12) {
+		case 0:
+			return True
+		case 1:
+			return False
+		}
+		return vars[r.Intn(len(vars))]
+	}
+	f := randomFormula(b, r, vars, depth-1)
+	g := randomFormula(b, r, vars, depth-1)
+
+	switch r.Intn(7) {
+	case 0:
+		return b.And(f, g)
+	case 1:
+		return b.Or(f, g)
+	case 2:
+		return b.Not(f)
+	case 3:
+		return b.Implies(f, g)
+	case 4:
+		return b.Iff(f, g)
+	case 5:
+		return b.Xor(f, g)
+	}
+	return b.Ite(f, g, randomFormula(b, r, vars, depth-1))
+}
+
+func TestHashing(t *testing.T) {
+	b := New(solver.New(config.New()))
+	x, y, z := b.Var("x"), b.Var("y"), b.Var("z")
+
+	for _, test := range []struct {
+		got  Formula
+		want Formula
+	}{
+		{b.Var("x"), x},
+		{b.And(x, y), b.And(y, x)},
+		{b.Or(x, y), b.Not(b.And(b.Not(x), b.Not(y)))},
+		{b.Xor(b.Not(x), y), b.Iff(x, y)},
+		{b.Ite(b.Not(x), y, z), b.Ite(x, z, y)},
+		{b.Ite(x, b.Not(y), z), b.Not(b.Ite(x, y, b.Not(z)))},
+		{b.Ite(x, y, False), b.And(x, y)},
+		{b.And(x, b.Not(x)), False},
+		{b.Or(x, True), True},
+		{b.Xor(x, x), False},
+		{b.And(), True},
+		{b.Or(), False},
+	} {
+		if test.got != test.want {
+			t.Fatalf("TestHashing() failed, got: %v, want: %v", test.got, test.want)
+		}
+	}
+}
+
+func TestSolve(t *testing.T) {
+	s := solver.New(config.New())
+	b := New(s)
+	x, y, z := b.Var("x"), b.Var("y"), b.Var("z")
+
+	b.Assert(b.Xor(x, y))
+	b.Assert(b.Implies(x, z))
+	b.Assert(b.Not(z))
+
+	if !s.Solve([]int{}) {
+		t.Fatalf("TestSolve() failed, got: false")
+	}
+	if got := fmt.Sprint(b.Model()); got != "map[x:false y:true z:false]" {
+		t.Fatalf("TestSolve() failed, got: %v", got)
+	}
+	if s.Solve([]int{b.Lit(b.Iff(x, y))}) {
+		t.Fatalf("TestSolve() failed, got: true")
+	}
+}
+
+func TestSolveRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	names := []string{"a", "b", "c", "d", "e"}
+
+	for i := 0; i < 500; i++ {
+		s := solver.New(config.New())
+		b := New(s)
+		vars := []Formula{}
+
+		for _, name := range names {
+			vars = append(vars, b.Var(name))
+		}
+		fs := []Formula{}
+
+		for j := 0; j < 1+r.Intn(3); j++ {
+			fs = append(fs, randomFormula(b, r, vars, 5))
+		}
+		want := false
+
+		for m := 0; m < 1<<len(names); m++ {
+			values := map[string]bool{}
+			for k, name := range names {
+				values[name] = m>>k&1 == 1
+			}
+			if b.Eval(b.And(fs...), values) {
+				want = true
+				break
+			}
+		}
+		for _, f := range fs {
+			b.Assert(f)
+		}
+		if got := s.Solve([]int{}); got != want {
+			t.Fatalf("TestSolveRandom() failed, got: %v", got)
+		}
+		if want && !b.Eval(b.And(fs...), b.Model()) {
+			t.Fatalf("TestSolveRandom() failed, got model: %v", b.Model())
+		}
+	}
+}
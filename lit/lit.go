@@ -10,7 +10,7 @@ const Undef = Lit(-1)
 // sorted.
 //
 // An unknown literal is denoted as -1.
-type Lit int
+type Lit int32
 
 // New returns a new literal given a 0-index variable, v, and whether the
 // literal is negative.
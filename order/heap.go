@@ -0,0 +1,142 @@
+package order
+
+// heap is a binary max-heap of variables keyed on their scores.
+type heap struct {
+	vars []int
+	// indices maps each var to its position in vars, or -1 when it's not in
+	// the heap.
+	indices []int
+	scores  *[]float64
+}
+
+// newHeap returns a new heap ordered by the given scores.
+func newHeap(scores *[]float64) *heap {
+	return &heap{
+		vars:    []int{},
+		indices: []int{},
+		scores:  scores,
+	}
+}
+
+// init rebuilds the heap from the given vars.
+func (h *heap) init(vars []int) {
+	for _, v := range h.vars {
+		h.indices[v] = -1
+	}
+	h.vars = h.vars[:0]
+
+	for _, v := range vars {
+		h.grow(v)
+		h.indices[v] = len(h.vars)
+		h.vars = append(h.vars, v)
+	}
+	n := h.len()
+	for i := n/2 - 1; i >= 0; i-- {
+		h.down(i, n)
+	}
+}
+
+// grow makes room for v in the heap's indices.
+func (h *heap) grow(v int) {
+	for len(h.indices) <= v {
+		h.indices = append(h.indices, -1)
+	}
+}
+
+// contains returns true if v is in the heap.
+func (h *heap) contains(v int) bool {
+	return v < len(h.indices) && h.indices[v] != -1
+}
+
+// push pushes a var onto the heap, if it isn't already in it.
+func (h *heap) push(v int) {
+	if h.contains(v) {
+		return
+	}
+	h.grow(v)
+	h.indices[v] = len(h.vars)
+	h.vars = append(h.vars, v)
+	h.up(h.len() - 1)
+}
+
+// fix fixes ordering of the heap after v's score changed.
+func (h *heap) fix(v int) {
+	if !h.contains(v) {
+		return
+	}
+	i := h.indices[v]
+
+	if !h.down(i, h.len()) {
+		h.up(i)
+	}
+}
+
+// len returns the number of vars in the heap.
+func (h *heap) len() int {
+	return len(h.vars)
+}
+
+// less returns true if the var at i has a lower score than the var at j.
+func (h *heap) less(i, j int) bool {
+	return (*h.scores)[h.vars[i]] < (*h.scores)[h.vars[j]]
+}
+
+// swap swaps the vars at i and j.
+func (h *heap) swap(i, j int) {
+	k, l := h.vars[i], h.vars[j]
+
+	h.vars[i], h.vars[j] = l, k
+	h.indices[k], h.indices[l] = j, i
+}
+
+// top returns the var with the highest score.
+func (h *heap) top() int {
+	return h.vars[0]
+}
+
+// pop pops the var with the highest score off of the heap.
+func (h *heap) pop() int {
+	n := len(h.vars) - 1
+	h.swap(0, n)
+	h.down(0, n)
+	v := h.vars[n]
+	h.vars = h.vars[:n]
+	h.indices[v] = -1
+
+	return v
+}
+
+// up percolates an element from the heap up, as adopted from Go's
+// container/heap package.
+func (h *heap) up(j int) {
+	for {
+		i := (j - 1) / 2
+		if i == j || !h.less(i, j) {
+			break
+		}
+		h.swap(i, j)
+		j = i
+	}
+}
+
+// down percolates an element from the heap down, as adopted from Go's
+// container/heap package.
+func (h *heap) down(i0, n int) bool {
+	i := i0
+	for {
+		j1 := 2*i + 1
+		if j1 >= n || j1 < 0 {
+			break
+		}
+		j := j1
+		if j2 := j1 + 1; j2 < n && h.less(j1, j2) {
+			j = j2
+		}
+		if !h.less(i, j) {
+			break
+		}
+		h.swap(i, j)
+		i = j
+	}
+	return i > i0
+}
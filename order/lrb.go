@@ -0,0 +1,132 @@
+package order
+
+import (
+	"github.com/ericr/saturday/lit"
+	"github.com/ericr/saturday/tribool"
+	"math"
+	"math/rand"
+)
+
+const (
+	// lrbStepSize is the initial step size of the learning rate average.
+	lrbStepSize = 0.4
+	// lrbStepSizeDec is how much the step size decreases after each conflict.
+	lrbStepSizeDec = 1e-6
+	// lrbStepSizeMin is the minimum step size.
+	lrbStepSizeMin = 0.06
+	// lrbLocalityDecay is how much the scores of unassigned variables decay
+	// per conflict.
+	lrbLocalityDecay = 0.95
+)
+
+// LRB orders variables by learning rate based branching, which rewards
+// variables by the rate at which they took part in conflicts while assigned,
+// as described by Liang et al. The scores of unassigned variables decay, to
+// favour variables taking part in recent conflicts.
+type LRB struct {
+	heap    *heap
+	assigns *[]tribool.Tribool
+	// scores is the exponential moving average of each variable's learning
+	// rate.
+	scores []float64
+	// assignedAt is the conflict count at which each variable was assigned.
+	assignedAt []int
+	// participated is the number of conflicts each variable took part in since
+	// it was assigned.
+	participated []int
+	// unassignedAt is the conflict count at which each variable was last
+	// unassigned or its score decayed.
+	unassignedAt []int
+	conflicts    int
+	stepSize     float64
+	rand         *rand.Rand
+}
+
+// NewLRB returns a new LRB order. Ties are broken randomly when r isn't nil.
+func NewLRB(assigns *[]tribool.Tribool, r *rand.Rand) *LRB {
+	o := &LRB{
+		assigns:      assigns,
+		scores:       []float64{},
+		assignedAt:   []int{},
+		participated: []int{},
+		unassignedAt: []int{},
+		stepSize:     lrbStepSize,
+		rand:         r,
+	}
+	o.heap = newHeap(&o.scores)
+
+	return o
+}
+
+// Init implements the Order interface.
+func (o *LRB) Init() {
+	o.heap.init(unassigned(*o.assigns))
+}
+
+// NewVar implements the Order interface.
+func (o *LRB) NewVar() {
+	v := len(o.scores)
+	o.scores = append(o.scores, initialScore(o.rand))
+	o.assignedAt = append(o.assignedAt, 0)
+	o.participated = append(o.participated, 0)
+	o.unassignedAt = append(o.unassignedAt, o.conflicts)
+	o.heap.push(v)
+}
+
+// Choose returns an unbound variable with the highest learning rate, or the
+// integer value of lit.Undef when there are no vars left to choose from.
+// Scores are decayed lazily, when their variable is about to be chosen.
+func (o *LRB) Choose() int {
+	a := *o.assigns
+
+	for o.heap.len() > 0 {
+		v := o.heap.top()
+
+		if !a[v].Undef() {
+			o.heap.pop()
+			continue
+		}
+		if age := o.conflicts - o.unassignedAt[v]; age > 0 {
+			o.scores[v] *= math.Pow(lrbLocalityDecay, float64(age))
+			o.unassignedAt[v] = o.conflicts
+			o.heap.fix(v)
+			continue
+		}
+		return o.heap.pop() + 1
+	}
+	return int(lit.Undef)
+}
+
+// Assign starts a new learning interval for v.
+func (o *LRB) Assign(v int) {
+	o.assignedAt[v] = o.conflicts
+	o.participated[v] = 0
+}
+
+// Push ends v's learning interval, rewarding it by the rate at which it took
+// part in conflicts, and makes it a candidate again.
+func (o *LRB) Push(v int) {
+	if interval := o.conflicts - o.assignedAt[v]; interval > 0 {
+		reward := float64(o.participated[v]) / float64(interval)
+		o.scores[v] = (1-o.stepSize)*o.scores[v] + o.stepSize*reward
+		o.heap.fix(v)
+	}
+	o.unassignedAt[v] = o.conflicts
+	o.heap.push(v)
+}
+
+// Bump records that the analyzed variables took part in a conflict.
+func (o *LRB) Bump(analyzed []int, learnt []int) {
+	for _, v := range analyzed {
+		o.participated[v]++
+	}
+}
+
+// Decay decreases the step size after a conflict.
+func (o *LRB) Decay() {
+	o.conflicts++
+
+	if o.stepSize > lrbStepSizeMin {
+		o.stepSize -= lrbStepSizeDec
+	}
+}
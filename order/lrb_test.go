@@ -0,0 +1,28 @@
+package order
+
+import (
+	"github.com/ericr/saturday/tribool"
+	"testing"
+)
+
+func TestLRBReward(t *testing.T) {
+	assigns := []tribool.Tribool{tribool.Undef, tribool.Undef}
+
+	ord := NewLRB(&assigns, nil)
+	ord.NewVar()
+	ord.NewVar()
+	ord.Assign(0)
+	ord.Assign(1)
+	ord.Bump([]int{1}, []int{1})
+	ord.Decay()
+	ord.Decay()
+	ord.Push(0)
+	ord.Push(1)
+
+	if ord.scores[0] != 0 || ord.scores[1] != 0.5*ord.stepSize {
+		t.Fatalf("Wrong rewards: %v", ord.scores)
+	}
+	if v := ord.Choose(); v != 2 {
+		t.Fatalf("Chose wrong var: %v", v)
+	}
+}
@@ -0,0 +1,140 @@
+package order
+
+import (
+	"github.com/ericr/saturday/lit"
+	"github.com/ericr/saturday/tribool"
+	"sort"
+)
+
+// VMTF orders variables in a queue, moving variables to the front whenever
+// they take part in a conflict (variable move-to-front).
+type VMTF struct {
+	assigns *[]tribool.Tribool
+	// prev and next link each variable to its neighbours in the queue, where
+	// -1 denotes no neighbour.
+	prev []int
+	next []int
+	// stamps is each variable's enqueue time, increasing towards the front.
+	stamps []int
+	stamp  int
+	// first and last are the back and front of the queue.
+	first int
+	last  int
+	// search is the variable to start searching for unassigned variables
+	// from. All variables in front of it are assigned.
+	search int
+	// bumped is the buffer of variables being bumped.
+	bumped []int
+}
+
+// NewVMTF returns a new VMTF order.
+func NewVMTF(assigns *[]tribool.Tribool) *VMTF {
+	return &VMTF{
+		assigns: assigns,
+		prev:    []int{},
+		next:    []int{},
+		stamps:  []int{},
+		first:   -1,
+		last:    -1,
+		search:  -1,
+	}
+}
+
+// Init implements the Order interface.
+func (o *VMTF) Init() {
+	o.search = o.last
+}
+
+// NewVar implements the Order interface.
+func (o *VMTF) NewVar() {
+	v := len(o.stamps)
+	o.prev = append(o.prev, -1)
+	o.next = append(o.next, -1)
+	o.stamps = append(o.stamps, 0)
+	o.enqueue(v)
+	o.search = v
+}
+
+// Choose returns the unassigned variable closest to the front of the queue,
+// or the integer value of lit.Undef when there are no vars left to choose
+// from.
+func (o *VMTF) Choose() int {
+	a := *o.assigns
+	v := o.search
+
+	for v != -1 && !a[v].Undef() {
+		v = o.prev[v]
+	}
+	if v == -1 {
+		return int(lit.Undef)
+	}
+	o.search = v
+
+	return v + 1
+}
+
+// Assign implements the Order interface.
+func (o *VMTF) Assign(v int) {}
+
+// Push implements the Order interface.
+func (o *VMTF) Push(v int) {
+	if o.search == -1 || o.stamps[v] > o.stamps[o.search] {
+		o.search = v
+	}
+}
+
+// Bump moves the variables of the learnt clause to the front of the queue,
+// keeping their relative order.
+func (o *VMTF) Bump(analyzed []int, learnt []int) {
+	o.bumped = append(o.bumped[:0], learnt...)
+	sort.Slice(o.bumped, func(i, j int) bool {
+		return o.stamps[o.bumped[i]] < o.stamps[o.bumped[j]]
+	})
+
+	for _, v := range o.bumped {
+		if v == o.last {
+			continue
+		}
+		o.dequeue(v)
+		o.enqueue(v)
+
+		if (*o.assigns)[v].Undef() {
+			o.search = v
+		}
+	}
+}
+
+// Decay implements the Order interface.
+func (o *VMTF) Decay() {}
+
+// enqueue puts v at the front of the queue.
+func (o *VMTF) enqueue(v int) {
+	o.prev[v] = o.last
+	o.next[v] = -1
+
+	if o.last == -1 {
+		o.first = v
+	} else {
+		o.next[o.last] = v
+	}
+	o.last = v
+	o.stamp++
+	o.stamps[v] = o.stamp
+}
+
+// dequeue unlinks v from the queue.
+func (o *VMTF) dequeue(v int) {
+	if o.prev[v] == -1 {
+		o.first = o.next[v]
+	} else {
+		o.next[o.prev[v]] = o.next[v]
+	}
+	if o.next[v] == -1 {
+		o.last = o.prev[v]
+	} else {
+		o.prev[o.next[v]] = o.prev[v]
+	}
+	if o.search == v {
+		o.search = o.prev[v]
+	}
+}
@@ -0,0 +1,75 @@
+package order
+
+import (
+	"github.com/ericr/saturday/lit"
+	"github.com/ericr/saturday/tribool"
+	"testing"
+)
+
+func TestVMTFChoose(t *testing.T) {
+	assigns := []tribool.Tribool{tribool.Undef, tribool.Undef, tribool.Undef}
+
+	ord := NewVMTF(&assigns)
+	ord.NewVar()
+	ord.NewVar()
+	ord.NewVar()
+
+	if v := ord.Choose(); v != 3 {
+		t.Fatalf("Chose wrong var: %v", v)
+	}
+	assigns[2] = tribool.True
+
+	if v := ord.Choose(); v != 2 {
+		t.Fatalf("Chose wrong var after assignment: %v", v)
+	}
+	assigns[1] = tribool.True
+	assigns[0] = tribool.True
+
+	if v := ord.Choose(); v != int(lit.Undef) {
+		t.Fatalf("Chose an assigned var: %v", v)
+	}
+	assigns[1] = tribool.Undef
+	ord.Push(1)
+
+	if v := ord.Choose(); v != 2 {
+		t.Fatalf("Chose wrong var after push: %v", v)
+	}
+}
+
+func TestVMTFBump(t *testing.T) {
+	assigns := []tribool.Tribool{tribool.Undef, tribool.Undef, tribool.Undef}
+
+	ord := NewVMTF(&assigns)
+	ord.NewVar()
+	ord.NewVar()
+	ord.NewVar()
+	ord.Bump([]int{0}, []int{0})
+
+	if v := ord.Choose(); v != 1 {
+		t.Fatalf("Chose wrong var after bump: %v", v)
+	}
+	if ord.first != 1 || ord.last != 0 {
+		t.Fatalf("Queue is wrong, first: %v, last: %v", ord.first, ord.last)
+	}
+}
+
+func TestVMTFBumpLearnt(t *testing.T) {
+	assigns := make([]tribool.Tribool, 5)
+
+	ord := NewVMTF(&assigns)
+	for range assigns {
+		ord.NewVar()
+	}
+	// Only the learnt clause's variables move, keeping their relative order.
+	ord.Bump([]int{3, 0, 1, 2}, []int{3, 0, 1})
+
+	want := []int{2, 4, 0, 1, 3}
+	for v, i := ord.first, 0; v != -1; v, i = ord.next[v], i+1 {
+		if v != want[i] {
+			t.Fatalf("Queue is wrong at %d, got: %v", i, v)
+		}
+	}
+	if v := ord.Choose(); v != 4 {
+		t.Fatalf("Chose wrong var after bump: %v", v)
+	}
+}
@@ -0,0 +1,103 @@
+package order
+
+import (
+	"github.com/ericr/saturday/lit"
+	"github.com/ericr/saturday/tribool"
+	"math/rand"
+)
+
+// VSIDS orders variables by their activity, which is bumped whenever they take
+// part in a conflict and decays over time.
+type VSIDS struct {
+	heap     *heap
+	assigns  *[]tribool.Tribool
+	activity []float64
+	varInc   float64
+	varDecay float64
+	rand     *rand.Rand
+}
+
+// NewVSIDS returns a new VSIDS order, where activity decays by the given
+// factor after every conflict. Ties are broken randomly when r isn't nil.
+func NewVSIDS(assigns *[]tribool.Tribool, decay float64, r *rand.Rand) *VSIDS {
+	o := &VSIDS{
+		assigns:  assigns,
+		activity: []float64{},
+		varInc:   1,
+		varDecay: 1 / decay,
+		rand:     r,
+	}
+	o.heap = newHeap(&o.activity)
+
+	return o
+}
+
+// Init implements the Order interface.
+func (o *VSIDS) Init() {
+	o.heap.init(unassigned(*o.assigns))
+}
+
+// NewVar implements the Order interface.
+func (o *VSIDS) NewVar() {
+	v := len(o.activity)
+	o.activity = append(o.activity, initialScore(o.rand))
+	o.heap.push(v)
+}
+
+// Choose returns an unbound variable with the highest activity, or the integer
+// value of lit.Undef when there are no vars left to choose from.
+func (o *VSIDS) Choose() int {
+	a := *o.assigns
+
+	for o.heap.len() > 0 {
+		if v := o.heap.pop(); a[v].Undef() {
+			return v + 1
+		}
+	}
+	return int(lit.Undef)
+}
+
+// Assign implements the Order interface.
+func (o *VSIDS) Assign(v int) {}
+
+// Push implements the Order interface.
+func (o *VSIDS) Push(v int) {
+	o.heap.push(v)
+}
+
+// Bump bumps the activity of the analyzed variables.
+func (o *VSIDS) Bump(analyzed []int, learnt []int) {
+	for _, v := range analyzed {
+		o.activity[v] += o.varInc
+
+		if o.activity[v] > 1e100 {
+			o.rescale()
+		}
+		o.heap.fix(v)
+	}
+}
+
+// Decay applies decay to the activity increment.
+func (o *VSIDS) Decay() {
+	o.varInc *= o.varDecay
+}
+
+// rescale rescales var activity.
+func (o *VSIDS) rescale() {
+	for i := range o.activity {
+		o.activity[i] *= 1e-100
+	}
+	o.varInc *= 1e-100
+}
+
+// unassigned returns all unassigned variables.
+func unassigned(assigns []tribool.Tribool) []int {
+	vars := []int{}
+
+	for v, a := range assigns {
+		if a.Undef() {
+			vars = append(vars, v)
+		}
+	}
+	return vars
+}
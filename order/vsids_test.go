@@ -0,0 +1,59 @@
+package order
+
+import (
+	"github.com/ericr/saturday/lit"
+	"github.com/ericr/saturday/tribool"
+	"testing"
+)
+
+func TestOrderPush(t *testing.T) {
+	assigns := []tribool.Tribool{tribool.True, tribool.False}
+
+	ord := NewVSIDS(&assigns, 0.95, nil)
+	ord.NewVar()
+	ord.NewVar()
+
+	if ord.heap.vars[1] != 1 {
+		t.Fatalf("Second element var order is wrong: %v", ord.heap.vars[1])
+	}
+}
+
+func TestOrderPop(t *testing.T) {
+	assigns := []tribool.Tribool{tribool.True, tribool.False}
+
+	ord := NewVSIDS(&assigns, 0.95, nil)
+	ord.NewVar()
+	ord.NewVar()
+
+	if v := ord.heap.pop(); v != 0 {
+		t.Fatalf("Popped var order is wrong: %v", v)
+	}
+}
+
+func TestOrderBump(t *testing.T) {
+	assigns := []tribool.Tribool{tribool.Undef, tribool.Undef, tribool.Undef}
+
+	ord := NewVSIDS(&assigns, 0.95, nil)
+	ord.NewVar()
+	ord.NewVar()
+	ord.NewVar()
+	ord.Bump([]int{2}, []int{2})
+	ord.Decay()
+	ord.Bump([]int{1}, []int{1})
+
+	if v := ord.Choose(); v != 2 {
+		t.Fatalf("Chose wrong var after bump: %v", v)
+	}
+}
+
+func TestOrderChooseAssigned(t *testing.T) {
+	assigns := []tribool.Tribool{tribool.True, tribool.False}
+
+	ord := NewVSIDS(&assigns, 0.95, nil)
+	ord.NewVar()
+	ord.NewVar()
+
+	if v := ord.Choose(); v != int(lit.Undef) {
+		t.Fatalf("Chose an assigned var: %v", v)
+	}
+}
@@ -0,0 +1,381 @@
+// Package qbf solves quantified boolean formulas in prenex CNF.
+package qbf
+
+import (
+	"github.com/ericr/saturday/config"
+	"github.com/ericr/saturday/encoding"
+	"github.com/ericr/saturday/solver"
+)
+
+// Solver solves a quantified boolean formula by clausal abstraction, as
+// described by Rabe and Tentrup. Each quantifier level is played by a SAT
+// solver choosing assignments to its variables, which is refined with the
+// counterexamples found by the levels inside it.
+//
+// A level only sees the clauses through two kinds of indicator variables: one
+// per clause telling whether it's satisfied by the outer levels, which are
+// assumed when solving, and, at universal levels, one per clause selecting it
+// to be falsified.
+type Solver struct {
+	conf    *config.Config
+	levels  []*level
+	clauses [][]int
+	nVars   int
+	// levelOf contains the index of each variable's level.
+	levelOf []int
+	// values contains the assignment chosen by each level for its variables.
+	values      []bool
+	result      bool
+	certificate []int
+	refinements int
+}
+
+// level is a quantifier level.
+type level struct {
+	universal bool
+	vars      []int
+	sat       *solver.Solver
+	// used is whether each clause's outer indicator occurs in the solver.
+	used []bool
+	// defined is whether each clause's selector is defined at a universal
+	// level.
+	defined []bool
+}
+
+// New returns a solver for the clauses, given as in DIMACS, quantified by the
+// prefix, outermost first. Free variables are existentially quantified
+// outermost.
+func New(prefix []encoding.Quantifier, clauses [][]int, c *config.Config) *Solver {
+	q := &Solver{conf: c}
+	quantified := map[int]bool{}
+
+	// Tautologies are always satisfied and can't be falsified by the universal
+	// player, so they're left out.
+	for _, clause := range clauses {
+		if !tautology(clause) {
+			q.clauses = append(q.clauses, clause)
+		}
+	}
+
+	for _, b := range prefix {
+		for _, v := range b.Vars {
+			quantified[v] = true
+			if v > q.nVars {
+				q.nVars = v
+			}
+		}
+	}
+	free := encoding.Quantifier{Universal: false}
+
+	for _, clause := range q.clauses {
+		for _, p := range clause {
+			if v := abs(p); !quantified[v] {
+				quantified[v] = true
+				free.Vars = append(free.Vars, v)
+				if v > q.nVars {
+					q.nVars = v
+				}
+			}
+		}
+	}
+
+	// Merge adjacent blocks with the same quantifier, skipping empty ones.
+	for _, b := range append([]encoding.Quantifier{free}, prefix...) {
+		n := len(q.levels)
+
+		switch {
+		case len(b.Vars) == 0:
+		case n > 0 && q.levels[n-1].universal == b.Universal:
+			q.levels[n-1].vars = append(q.levels[n-1].vars, b.Vars...)
+		default:
+			q.levels = append(q.levels, &level{
+				universal: b.Universal,
+				vars:      append([]int{}, b.Vars...),
+			})
+		}
+	}
+	if len(q.levels) == 0 {
+		q.levels = append(q.levels, &level{universal: false})
+	}
+	q.levelOf = make([]int, q.nVars+1)
+	q.values = make([]bool, q.nVars+1)
+
+	for i, l := range q.levels {
+		for _, v := range l.vars {
+			q.levelOf[v] = i
+		}
+		l.sat = solver.New(c)
+		l.used = make([]bool, len(q.clauses))
+		l.defined = make([]bool, len(q.clauses))
+	}
+	q.initLevels()
+
+	return q
+}
+
+// initLevels adds the constraints each level must meet to win regardless of
+// the levels inside it.
+func (q *Solver) initLevels() {
+	firstExists := 0
+	if q.levels[0].universal {
+		firstExists = 1
+	}
+
+	for c := range q.clauses {
+		// A clause must be satisfied by the innermost existential level it
+		// occurs in, since the universal literals after it can be falsified.
+		i := q.lastExists(c)
+		if i < firstExists {
+			i = firstExists
+		}
+		if i < len(q.levels) {
+			l := q.levels[i]
+			l.used[c] = true
+			l.sat.AddClause(append([]int{q.outer(c)}, q.litsAt(c, i)...))
+		}
+	}
+
+	// The innermost universal level wins by falsifying any clause.
+	if i := len(q.levels) - 1; q.levels[i].universal {
+		l := q.levels[i]
+		selectors := []int{}
+
+		for c := range q.clauses {
+			q.define(l, i, c)
+			selectors = append(selectors, q.selector(c))
+		}
+		l.sat.AddClause(selectors)
+	}
+}
+
+// Solve solves the formula, returning true when it's true.
+func (q *Solver) Solve() bool {
+	present := make([]bool, len(q.clauses))
+	for c := range present {
+		present[c] = true
+	}
+	q.result, _ = q.solve(0, present)
+	q.certificate = []int{}
+
+	// The outermost level's last move is winning when it owns the result.
+	if top := q.levels[0]; top.universal != q.result {
+		for _, v := range top.vars {
+			if q.values[v] {
+				q.certificate = append(q.certificate, v)
+			} else {
+				q.certificate = append(q.certificate, -v)
+			}
+		}
+	}
+	return q.result
+}
+
+// Certificate returns the winning assignment of the outermost level found by
+// the last call to Solve: of the outermost existential block when the formula
+// is true, or of the outermost universal block when it's false. It's empty
+// when the winner doesn't own the outermost level.
+func (q *Solver) Certificate() []int {
+	return q.certificate
+}
+
+// NRefinements returns the number of counterexamples the levels were refined
+// with.
+func (q *Solver) NRefinements() int {
+	return q.refinements
+}
+
+// NLevels returns the number of quantifier levels.
+func (q *Solver) NLevels() int {
+	return len(q.levels)
+}
+
+// solve plays level i on the clauses present, i.e. not satisfied by the outer
+// levels. It returns whether the existential player wins along with the
+// clauses proving it: when the existential player wins, it wins whenever the
+// clauses present are among them, and when it loses, it loses whenever they
+// are all present.
+func (q *Solver) solve(i int, present []bool) (bool, []bool) {
+	proof := make([]bool, len(q.clauses))
+
+	if i == len(q.levels) {
+		// All variables are assigned, the existential player wins when all
+		// clauses are satisfied.
+		for c, p := range present {
+			if p {
+				proof[c] = true
+				return false, proof
+			}
+		}
+		return true, proof
+	}
+	l := q.levels[i]
+
+	for {
+		assumps := []int{}
+
+		// Indicators are only assumed in the direction that restricts the
+		// level's player, so that refutations only consist of those.
+		for c, used := range l.used {
+			switch {
+			case !used:
+			case l.universal && !present[c]:
+				assumps = append(assumps, q.outer(c))
+			case !l.universal && present[c]:
+				assumps = append(assumps, -q.outer(c))
+			}
+		}
+		if !l.sat.Solve(assumps) {
+			// The level loses whenever the clauses whose indicators refuted it
+			// are present, or satisfied for the universal player.
+			for _, p := range l.sat.FailedAssumptions() {
+				proof[abs(p)-q.nVars-1] = true
+			}
+			if l.universal {
+				for c := range proof {
+					proof[c] = !proof[c]
+				}
+			}
+			return l.universal, proof
+		}
+		q.setValues(i)
+
+		satisfied := make([]bool, len(q.clauses))
+		next := make([]bool, len(q.clauses))
+
+		for c, p := range present {
+			satisfied[c] = q.satisfiedAt(c, i)
+			next[c] = p && !satisfied[c]
+		}
+		wins, inner := q.solve(i+1, next)
+
+		if l.universal {
+			if !wins {
+				return false, inner
+			}
+			// Falsify a clause the existential player's answer doesn't cover.
+			selectors := []int{}
+
+			for c, covered := range inner {
+				if !covered {
+					q.define(l, i, c)
+					selectors = append(selectors, q.selector(c))
+				}
+			}
+			l.sat.AddClause(selectors)
+		} else {
+			if wins {
+				for c, s := range satisfied {
+					inner[c] = inner[c] || s
+				}
+				return true, inner
+			}
+			// Satisfy a clause of the universal player's answer.
+			clause := []int{}
+
+			for c, needed := range inner {
+				if needed {
+					l.used[c] = true
+					clause = append(clause, q.outer(c))
+					clause = append(clause, q.litsAt(c, i)...)
+				}
+			}
+			l.sat.AddClause(clause)
+		}
+		q.refinements++
+	}
+}
+
+// setValues stores the assignment of level i's variables found by its
+// solver.
+func (q *Solver) setValues(i int) {
+	l := q.levels[i]
+
+	for _, v := range l.vars {
+		q.values[v] = false
+	}
+	for _, p := range l.sat.Answer() {
+		if p > 0 && p <= q.nVars && q.levelOf[p] == i {
+			q.values[p] = true
+		}
+	}
+}
+
+// define defines the selector of clause c at universal level i, which implies
+// that c is falsified by the levels up to i.
+func (q *Solver) define(l *level, i int, c int) {
+	if l.defined[c] {
+		return
+	}
+	l.defined[c] = true
+	l.used[c] = true
+	l.sat.AddClause([]int{-q.selector(c), -q.outer(c)})
+
+	for _, p := range q.litsAt(c, i) {
+		l.sat.AddClause([]int{-q.selector(c), -p})
+	}
+}
+
+// outer returns the variable indicating that clause c is satisfied by the
+// outer levels.
+func (q *Solver) outer(c int) int {
+	return q.nVars + 1 + c
+}
+
+// selector returns the variable selecting clause c to be falsified.
+func (q *Solver) selector(c int) int {
+	return q.nVars + 1 + len(q.clauses) + c
+}
+
+// litsAt returns the literals of clause c at level i.
+func (q *Solver) litsAt(c int, i int) []int {
+	lits := []int{}
+
+	for _, p := range q.clauses[c] {
+		if q.levelOf[abs(p)] == i {
+			lits = append(lits, p)
+		}
+	}
+	return lits
+}
+
+// satisfiedAt returns whether clause c has a literal at level i that's true.
+func (q *Solver) satisfiedAt(c int, i int) bool {
+	for _, p := range q.clauses[c] {
+		if q.levelOf[abs(p)] == i && q.values[abs(p)] == (p > 0) {
+			return true
+		}
+	}
+	return false
+}
+
+// lastExists returns the innermost existential level of clause c's literals,
+// or -1 when it has none.
+func (q *Solver) lastExists(c int) int {
+	last := -1
+
+	for _, p := range q.clauses[c] {
+		if i := q.levelOf[abs(p)]; !q.levels[i].universal && i > last {
+			last = i
+		}
+	}
+	return last
+}
+
+// tautology returns whether the clause contains a literal and its negation.
+func tautology(clause []int) bool {
+	for i, p := range clause {
+		for _, q := range clause[i+1:] {
+			if p == -q {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
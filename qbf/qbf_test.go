@@ -0,0 +1,153 @@
+package qbf
+
+import (
+	"fmt"
+	"github.com/ericr/saturday/config"
+	"github.com/ericr/saturday/encoding"
+	"math/rand"
+	"testing"
+)
+
+// exists returns a block of existentially quantified variables.
+func exists(vars ...int) encoding.Quantifier {
+	return encoding.Quantifier{Universal: false, Vars: vars}
+}
+
+// forall returns a block of universally quantified variables.
+func forall(vars ...int) encoding.Quantifier {
+	return encoding.Quantifier{Universal: true, Vars: vars}
+}
+
+// evaluate decides the formula by expanding every quantifier.
+func evaluate(prefix []encoding.Quantifier, clauses [][]int, values map[int]bool) bool {
+	if len(prefix) == 0 {
+		for _, clause := range clauses {
+			satisfied := false
+
+			for _, p := range clause {
+				if v := abs(p); values[v] == (p > 0) {
+					satisfied = true
+				}
+			}
+			if !satisfied {
+				return false
+			}
+		}
+		return true
+	}
+	b := prefix[0]
+	if len(b.Vars) == 0 {
+		return evaluate(prefix[1:], clauses, values)
+	}
+	rest := append([]encoding.Quantifier{{Universal: b.Universal, Vars: b.Vars[1:]}},
+		prefix[1:]...)
+
+	for _, val := range []bool{false, true} {
+		values[b.Vars[0]] = val
+		result := evaluate(rest, clauses, values)
+		delete(values, b.Vars[0])
+
+		if result != b.Universal {
+			return result
+		}
+	}
+	return b.Universal
+}
+
+// randomQBF returns a random formula over n variables split into alternating
+// blocks, leaving the last variable free.
+func randomQBF(r *rand.Rand, n int, blocks int, m int) ([]encoding.Quantifier, [][]int) {
+	prefix := []encoding.Quantifier{}
+
+	for v := 1; v < n; v++ {
+		b := (v - 1) * blocks / (n - 1)
+		if b == len(prefix) {
+			prefix = append(prefix, encoding.Quantifier{Universal: b%2 == 1})
+		}
+		prefix[b].Vars = append(prefix[b].Vars, v)
+	}
+	clauses := [][]int{}
+
+	for i := 0; i < m; i++ {
+		clause := []int{}
+
+		for j := 0; j < 3; j++ {
+			p := r.Intn(n) + 1
+			if r.Intn(2) == 0 {
+				p = -p
+			}
+			clause = append(clause, p)
+		}
+		clauses = append(clauses, clause)
+	}
+	return prefix, clauses
+}
+
+func TestSolve(t *testing.T) {
+	conf := config.New()
+
+	for _, test := range []struct {
+		prefix  []encoding.Quantifier
+		clauses [][]int
+		want    bool
+		cert    string
+	}{
+		{
+			[]encoding.Quantifier{exists(1), forall(2)},
+			[][]int{{1, 2}, {1, -2}},
+			true, "[1]",
+		},
+		{
+			[]encoding.Quantifier{forall(2), exists(1)},
+			[][]int{{1, 2}, {-1, -2}},
+			true, "[]",
+		},
+		{
+			[]encoding.Quantifier{exists(1), forall(2)},
+			[][]int{{1, 2}, {-1, -2}},
+			false, "[]",
+		},
+		{
+			[]encoding.Quantifier{forall(1), exists(2)},
+			[][]int{{1, 2}, {1, -2}},
+			false, "[-1]",
+		},
+	} {
+		q := New(test.prefix, test.clauses, conf)
+
+		if got := q.Solve(); got != test.want {
+			t.Fatalf("TestSolve() failed, got: %v", got)
+		}
+		if got := fmt.Sprint(q.Certificate()); got != test.cert {
+			t.Fatalf("TestSolve() failed, got certificate: %v", got)
+		}
+	}
+}
+
+func TestSolveRandom(t *testing.T) {
+	conf := config.New()
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 300; i++ {
+		prefix, clauses := randomQBF(r, 10, 1+i%4, 8+r.Intn(20))
+		q := New(prefix, clauses, conf)
+		want := evaluate(append([]encoding.Quantifier{exists(10)}, prefix...),
+			clauses, map[int]bool{})
+
+		if got := q.Solve(); got != want {
+			t.Fatalf("TestSolveRandom() failed on %v %v, got: %v", prefix, clauses, got)
+		}
+		// A certificate of the outermost existential block must keep the
+		// formula true.
+		if cert := q.Certificate(); want && len(cert) > 0 {
+			values := map[int]bool{}
+			for _, p := range cert {
+				values[abs(p)] = p > 0
+			}
+			if !evaluate(prefix[1:], clauses, values) {
+				t.Fatalf("TestSolveRandom() failed on %v %v, got certificate: %v",
+					prefix, clauses, cert)
+			}
+		}
+	}
+}
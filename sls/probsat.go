@@ -0,0 +1,33 @@
+package sls
+
+import (
+	"github.com/ericr/saturday/lit"
+	"math"
+)
+
+const (
+	// probSATEps and probSATCb parameterize ProbSAT's polynomial break
+	// function, (eps + break)^-cb.
+	probSATEps = 0.9
+	probSATCb  = 2.06
+)
+
+// pickProbSAT picks a variable of the clause with a probability that falls
+// polynomially with its break count.
+func pickProbSAT(s *Searcher, clause []lit.Lit) int {
+	probs := make([]float64, len(clause))
+	sum := 0.0
+
+	for i, p := range clause {
+		probs[i] = math.Pow(probSATEps+float64(s.breakCount(p.Index())), -probSATCb)
+		sum += probs[i]
+	}
+	r := s.rand.Float64() * sum
+
+	for i, p := range clause {
+		if r -= probs[i]; r <= 0 {
+			return p.Index()
+		}
+	}
+	return clause[len(clause)-1].Index()
+}
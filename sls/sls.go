@@ -0,0 +1,219 @@
+// Package sls implements stochastic local search for satisfiable CNF
+// problems.
+package sls
+
+import (
+	"github.com/ericr/saturday/config"
+	"github.com/ericr/saturday/lit"
+	"math/rand"
+)
+
+// picker picks the variable to flip from an unsatisfied clause.
+type picker func(s *Searcher, clause []lit.Lit) int
+
+// Searcher flips variables of a complete assignment until it satisfies all
+// clauses, picking the variables to flip from unsatisfied clauses.
+type Searcher struct {
+	clauses [][]lit.Lit
+	// occs lists the clauses each literal occurs in, indexed by the literal.
+	occs [][]int
+	// assigns contains the current assignment indexed on variables.
+	assigns []bool
+	// numTrue contains the number of true literals in each clause.
+	numTrue []int
+	// unsat lists the unsatisfied clauses, and unsatIdx each clause's index in
+	// it, or -1 when satisfied.
+	unsat    []int
+	unsatIdx []int
+	// best is the assignment with the fewest unsatisfied clauses so far.
+	best      []bool
+	bestUnsat int
+	pick      picker
+	rand      *rand.Rand
+	flips     int
+}
+
+// New returns a searcher for the clauses, given as in DIMACS, using the local
+// search algorithm named by the config. Every variable starts out false.
+func New(clauses [][]int, c *config.Config) *Searcher {
+	s := &Searcher{
+		clauses:  make([][]lit.Lit, 0, len(clauses)),
+		numTrue:  make([]int, len(clauses)),
+		unsatIdx: make([]int, len(clauses)),
+		rand:     rand.New(rand.NewSource(c.Seed)),
+	}
+	switch c.LocalSearch {
+	case config.LocalSearchWalkSAT:
+		s.pick = pickWalkSAT
+	default:
+		s.pick = pickProbSAT
+	}
+	nVars := 0
+
+	for _, ps := range clauses {
+		clause := []lit.Lit{}
+
+		for _, p := range ps {
+			q := lit.NewFromInt(p)
+
+			if !contains(clause, q) {
+				clause = append(clause, q)
+			}
+			if q.Var() > nVars {
+				nVars = q.Var()
+			}
+		}
+		s.clauses = append(s.clauses, clause)
+	}
+	s.occs = make([][]int, 2*nVars)
+
+	for i, clause := range s.clauses {
+		for _, p := range clause {
+			s.occs[p] = append(s.occs[p], i)
+		}
+	}
+	s.assigns = make([]bool, nVars)
+	s.SetPhases(s.assigns)
+
+	return s
+}
+
+// SetSeed reseeds the searcher's source of randomness.
+func (s *Searcher) SetSeed(seed int64) {
+	s.rand.Seed(seed)
+}
+
+// SetPhases replaces the current assignment, indexed on variables starting at
+// zero. Variables beyond the phases given keep their value.
+func (s *Searcher) SetPhases(phases []bool) {
+	copy(s.assigns, phases)
+	s.unsat = s.unsat[:0]
+
+	for i, clause := range s.clauses {
+		s.numTrue[i] = 0
+
+		for _, p := range clause {
+			if s.value(p) {
+				s.numTrue[i]++
+			}
+		}
+		s.unsatIdx[i] = -1
+
+		if s.numTrue[i] == 0 {
+			s.addUnsat(i)
+		}
+	}
+	s.best = append(s.best[:0], s.assigns...)
+	s.bestUnsat = len(s.unsat)
+}
+
+// Solve flips variables until the assignment satisfies all clauses, or until
+// maxFlips variables were flipped when positive. It returns true when
+// satisfied.
+func (s *Searcher) Solve(maxFlips int) bool {
+	for i := 0; maxFlips <= 0 || i < maxFlips; i++ {
+		if len(s.unsat) == 0 {
+			return true
+		}
+		clause := s.clauses[s.unsat[s.rand.Intn(len(s.unsat))]]
+
+		// The empty clause can't be satisfied.
+		if len(clause) == 0 {
+			return false
+		}
+		s.flip(s.pick(s, clause))
+		s.flips++
+
+		if len(s.unsat) < s.bestUnsat {
+			s.best = append(s.best[:0], s.assigns...)
+			s.bestUnsat = len(s.unsat)
+		}
+	}
+	return len(s.unsat) == 0
+}
+
+// Best returns the assignment with the fewest unsatisfied clauses found so
+// far, indexed on variables starting at zero.
+func (s *Searcher) Best() []bool {
+	return s.best
+}
+
+// Answer returns the current assignment as CNF.
+func (s *Searcher) Answer() []int {
+	ps := make([]int, len(s.assigns))
+
+	for v, b := range s.assigns {
+		ps[v] = lit.New(v, !b).Int()
+	}
+	return ps
+}
+
+// NUnsat returns the number of clauses the current assignment doesn't
+// satisfy.
+func (s *Searcher) NUnsat() int {
+	return len(s.unsat)
+}
+
+// NFlips returns the number of flips made.
+func (s *Searcher) NFlips() int {
+	return s.flips
+}
+
+// value returns p's value under the current assignment.
+func (s *Searcher) value(p lit.Lit) bool {
+	return s.assigns[p.Index()] != p.Sign()
+}
+
+// breakCount returns the number of clauses that flipping v would leave
+// unsatisfied.
+func (s *Searcher) breakCount(v int) int {
+	n := 0
+	for _, i := range s.occs[lit.New(v, !s.assigns[v])] {
+		if s.numTrue[i] == 1 {
+			n++
+		}
+	}
+	return n
+}
+
+// flip flips the value of v.
+func (s *Searcher) flip(v int) {
+	p := lit.New(v, !s.assigns[v])
+	s.assigns[v] = !s.assigns[v]
+
+	for _, i := range s.occs[p] {
+		if s.numTrue[i]--; s.numTrue[i] == 0 {
+			s.addUnsat(i)
+		}
+	}
+	for _, i := range s.occs[p.Not()] {
+		if s.numTrue[i]++; s.numTrue[i] == 1 {
+			s.removeUnsat(i)
+		}
+	}
+}
+
+// addUnsat adds clause i to the unsatisfied clauses.
+func (s *Searcher) addUnsat(i int) {
+	s.unsatIdx[i] = len(s.unsat)
+	s.unsat = append(s.unsat, i)
+}
+
+// removeUnsat removes clause i from the unsatisfied clauses.
+func (s *Searcher) removeUnsat(i int) {
+	last := s.unsat[len(s.unsat)-1]
+	s.unsat[s.unsatIdx[i]] = last
+	s.unsatIdx[last] = s.unsatIdx[i]
+	s.unsat = s.unsat[:len(s.unsat)-1]
+	s.unsatIdx[i] = -1
+}
+
+// contains returns true if p is in the clause.
+func contains(clause []lit.Lit, p lit.Lit) bool {
+	for _, q := range clause {
+		if q == p {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package sls
+
+import (
+	"github.com/ericr/saturday/config"
+	"math/rand"
+	"testing"
+)
+
+// planted3SAT returns a random 3-SAT instance satisfied by a planted model.
+func planted3SAT(seed int64, n int, ratio float64) [][]int {
+	r := rand.New(rand.NewSource(seed))
+	model := make([]bool, n+1)
+	clauses := [][]int{}
+
+	for v := range model {
+		model[v] = r.Intn(2) == 0
+	}
+	for len(clauses) < int(float64(n)*ratio) {
+		clause := []int{}
+		sat := false
+
+		for i := 0; i < 3; i++ {
+			v := r.Intn(n) + 1
+			p := v
+
+			if r.Intn(2) == 0 {
+				p = -v
+			}
+			sat = sat || (p > 0) == model[v]
+			clause = append(clause, p)
+		}
+		if sat {
+			clauses = append(clauses, clause)
+		}
+	}
+	return clauses
+}
+
+func testSolve(t *testing.T, algorithm string) {
+	conf := config.New()
+	conf.LocalSearch = algorithm
+
+	for seed := int64(0); seed < 4; seed++ {
+		clauses := planted3SAT(seed, 200, 4.0)
+		s := New(clauses, conf)
+
+		if !s.Solve(1000000) {
+			t.Fatalf("TestSolve() failed: %d unsatisfied", s.NUnsat())
+		}
+		model := map[int]bool{}
+		for _, p := range s.Answer() {
+			model[p] = true
+		}
+		for _, c := range clauses {
+			if !model[c[0]] && !model[c[1]] && !model[c[2]] {
+				t.Fatalf("TestSolve() failed, clause not satisfied: %v", c)
+			}
+		}
+	}
+}
+
+func TestProbSAT(t *testing.T) {
+	testSolve(t, config.LocalSearchProbSAT)
+}
+
+func TestWalkSAT(t *testing.T) {
+	testSolve(t, config.LocalSearchWalkSAT)
+}
+
+func TestEmptyClause(t *testing.T) {
+	s := New([][]int{{1, 2}, {}}, config.New())
+
+	if s.Solve(100) {
+		t.Fatalf("TestEmptyClause() failed: sat")
+	}
+}
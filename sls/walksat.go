@@ -0,0 +1,29 @@
+package sls
+
+import "github.com/ericr/saturday/lit"
+
+// walkSATNoise is the probability of a random walk step when every variable of
+// the clause would break another clause.
+const walkSATNoise = 0.567
+
+// pickWalkSAT picks a variable of the clause that breaks no other clause if
+// there is one, and otherwise either a random variable or one with the lowest
+// break count.
+func pickWalkSAT(s *Searcher, clause []lit.Lit) int {
+	best, bestBreaks := -1, 0
+
+	for _, p := range clause {
+		breaks := s.breakCount(p.Index())
+
+		if breaks == 0 {
+			return p.Index()
+		}
+		if best < 0 || breaks < bestBreaks {
+			best, bestBreaks = p.Index(), breaks
+		}
+	}
+	if s.rand.Float64() < walkSATNoise {
+		return clause[s.rand.Intn(len(clause))].Index()
+	}
+	return best
+}
@@ -6,37 +6,46 @@ import (
 	"strings"
 )
 
-// Clause is a CNF clause.
+// Learnt clause tiers, as used by Glucose-style clause database management.
+const (
+	// tierCore clauses are kept forever.
+	tierCore = iota
+	// tierTwo clauses are kept while they keep being used in conflicts.
+	tierTwo
+	// tierLocal clauses are subject to reduceDB().
+	tierLocal
+)
+
+// Clause is a handle to a CNF clause stored in the solver's clause arena.
 type Clause struct {
-	solver   *Solver
-	lits     []lit.Lit
-	learnt   bool
-	activity float64
+	solver *Solver
+	ref    cref
 }
 
-// newClause returns a new initialized clause or false on top-level conflict.
-func newClause(s *Solver, lits []lit.Lit, learnt bool) (bool, *Clause) {
-	c := &Clause{
-		solver: s,
-		lits:   lits,
-		learnt: learnt,
-	}
+// clause returns a handle to the referenced clause.
+func (s *Solver) clause(c cref) Clause {
+	return Clause{solver: s, ref: c}
+}
+
+// newClause returns a new initialized clause, or crefUndef when no clause is
+// needed, or false on top-level conflict.
+func newClause(s *Solver, lits []lit.Lit, learnt bool) (bool, cref) {
 	if !learnt {
 		// Sort literals so we can easily detect tautologies.
-		sort.Sort(c)
+		sort.Slice(lits, func(i, j int) bool { return lits[i] < lits[j] })
 
 		idx := 0
 		last := lit.Undef
 
 		// Normalize clause.
-		for _, p := range c.lits {
+		for _, p := range lits {
 			switch {
 			case s.litValue(p).True():
 				// Return on clause already true.
-				return true, c
+				return true, crefUndef
 			case p == last.Not():
 				// Return on tautology.
-				return true, c
+				return true, crefUndef
 			case s.litValue(p).False():
 				// Remove false literals.
 				continue
@@ -45,90 +54,98 @@ func newClause(s *Solver, lits []lit.Lit, learnt bool) (bool, *Clause) {
 				continue
 			}
 			if p != last {
-				c.lits[idx] = p
+				lits[idx] = p
 				last = p
 				idx++
 			}
 		}
-		c.lits = c.lits[:idx]
+		lits = lits[:idx]
 	}
-
-	switch c.Len() {
-	case 0:
+	if len(lits) == 0 {
 		// Return with conflict on empty clause.
-		return false, c
-	case 1:
+		return false, crefUndef
+	}
+	c := s.clause(s.ca.alloc(lits, learnt))
+
+	if c.Len() == 1 {
 		// Unit detected, enqueue it.
-		return s.enqueue(c.lits[0], c), c
+		return s.enqueue(lits[0], clauseReason(c.ref)), c.ref
 	}
+	lits = c.lits()
 
 	if learnt {
 		// Pick a second literal to watch.
 		maxIdx := c.highestDecisionLevelIdx()
-		c.lits[1], c.lits[maxIdx] = c.lits[maxIdx], c.lits[1]
+		lits[1], lits[maxIdx] = lits[maxIdx], lits[1]
 
 		// Newly learnt clauses are considered active.
-		c.solver.claBumpActivity(c)
-
-		for i := 0; i < c.Len(); i++ {
-			c.solver.varBumpActivity(c.lits[i])
-		}
+		s.claBumpActivity(c.ref)
 	}
 	// Watch the clause.
-	c.addToWatcher(c.lits[0].Not())
-	c.addToWatcher(c.lits[1].Not())
+	c.addToWatcher(lits[0].Not())
+	c.addToWatcher(lits[1].Not())
 
-	return true, c
+	return true, c.ref
 }
 
 // String implements the Stringer interface.
-func (c *Clause) String() string {
+func (c Clause) String() string {
 	return strings.Join(c.asStrings(), ",")
 }
 
 // Len returns the length of the clause.
-func (c *Clause) Len() int {
-	return len(c.lits)
+func (c Clause) Len() int {
+	return c.solver.ca.size(c.ref)
 }
 
-// Swap swaps two literals within the clause.
-func (c *Clause) Swap(i, j int) {
-	c.lits[i], c.lits[j] = c.lits[j], c.lits[i]
+// lits returns the clause's literals.
+func (c Clause) lits() []lit.Lit {
+	return c.solver.ca.lits(c.ref)
 }
 
-// Less compares two literals within the clause.
-func (c *Clause) Less(i, j int) bool {
-	return c.lits[i] < c.lits[j]
+// learnt returns true if the clause is a learnt clause.
+func (c Clause) learnt() bool {
+	return c.solver.ca.flag(c.ref, flagLearnt)
 }
 
 // locked returns true if the clause is locked.
-func (c *Clause) locked() bool {
-	return c.solver.reason[c.lits[0].Index()] == c
+func (c Clause) locked() bool {
+	return c.solver.reason[c.lits()[0].Index()].clause == c.ref
 }
 
-// addToWatcher adds this clause to p's watch list.
-func (c *Clause) addToWatcher(p lit.Lit) {
-	c.solver.watches[p] = append(c.solver.watches[p], c)
+// addToWatcher adds this clause to p's watch list, blocked by the other
+// watched literal.
+func (c Clause) addToWatcher(p lit.Lit) {
+	lits := c.lits()
+	w := watcher{clause: c.ref, blocker: lits[0], binary: len(lits) == 2}
+	if w.blocker == p.Not() {
+		w.blocker = lits[1]
+	}
+	c.solver.watches[p] = append(c.solver.watches[p], w)
 }
 
 // removeFromWatcher removes this clause to p's watch list.
-func (c *Clause) removeFromWatcher(p lit.Lit) {
-	for idx, clause := range c.solver.watches[p] {
-		if clause == c {
-			ridx := len(c.solver.watches[p])-1
-			c.solver.watches[p][idx] = c.solver.watches[p][ridx]
-			c.solver.watches[p] = c.solver.watches[p][:ridx]
+func (c Clause) removeFromWatcher(p lit.Lit) {
+	ws := c.solver.watches[p]
+
+	for idx, w := range ws {
+		if w.clause == c.ref {
+			ridx := len(ws) - 1
+			ws[idx] = ws[ridx]
+			c.solver.watches[p] = ws[:ridx]
+
+			return
 		}
 	}
 }
 
 // highestDecisionLevelIdx returns the clause index of p with the highest
 // decision level.
-func (c *Clause) highestDecisionLevelIdx() int {
+func (c Clause) highestDecisionLevelIdx() int {
 	max := 0
 	maxiIdx := 0
 
-	for idx, p := range c.lits {
+	for idx, p := range c.lits() {
 		dl := c.solver.level[p.Index()]
 
 		if dl > max {
@@ -140,30 +157,37 @@ func (c *Clause) highestDecisionLevelIdx() int {
 }
 
 // asStrings returns a clause as an array of strings.
-func (c *Clause) asStrings() []string {
+func (c Clause) asStrings() []string {
 	litStrs := []string{}
 
-	for _, lit := range c.lits {
+	for _, lit := range c.lits() {
 		litStrs = append(litStrs, lit.String())
 	}
 	return litStrs
 }
 
 // asInts returns a clause as an array of integers.
-func (c *Clause) asInts() []int {
+func (c Clause) asInts() []int {
 	litInts := []int{}
 
-	for _, l := range c.lits {
+	for _, l := range c.lits() {
 		litInts = append(litInts, l.Int())
 	}
 	return litInts
 }
 
-// remove removes the clause from the solver.
-func (c *Clause) remove() {
+// remove removes the clause from the solver, freeing it in the arena.
+func (c Clause) remove() {
+	lits := c.lits()
+
 	for i := 0; i < 2; i++ {
-		if c.Len() > i {
-			c.removeFromWatcher(c.lits[i].Not())
+		if len(lits) > i {
+			c.removeFromWatcher(lits[i].Not())
 		}
 	}
+	// Top-level assignments keep no reason, since they're never analyzed.
+	if c.locked() {
+		c.solver.reason[lits[0].Index()] = noReason
+	}
+	c.solver.ca.free(c.ref)
 }
@@ -0,0 +1,150 @@
+package solver
+
+import (
+	"github.com/ericr/saturday/lit"
+	"math"
+)
+
+// cref is a reference to a clause in the clause arena, i.e. the offset of its
+// header.
+type cref int32
+
+const (
+	// crefUndef refers to no clause.
+	crefUndef = cref(-1)
+	// crefExternal refers to the reason of an external propagation, which
+	// hasn't been asked for yet.
+	crefExternal = cref(-2)
+)
+
+// Clause header layout. Every clause is stored as a header followed by its
+// literals.
+const (
+	hdrSize = iota
+	hdrFlags
+	hdrLBD
+	hdrActivity
+	hdrTouched
+	hdrLen
+)
+
+// Clause header flags. The clause's tier is stored above the flags.
+const (
+	flagLearnt = 1 << iota
+	flagDeleted
+	flagUsed
+	flagRelocated
+	flagTierShift = iota
+)
+
+// clauseArena stores clauses contiguously, avoiding a heap object per clause.
+type clauseArena struct {
+	mem []lit.Lit
+	// wasted is the number of cells taken by deleted or shrunk clauses.
+	wasted int
+}
+
+// newClauseArena returns a new arena with room for n cells.
+func newClauseArena(n int) *clauseArena {
+	return &clauseArena{mem: make([]lit.Lit, 0, n)}
+}
+
+// alloc stores a new clause in the arena and returns its reference.
+func (a *clauseArena) alloc(lits []lit.Lit, learnt bool) cref {
+	c := cref(len(a.mem))
+	hdr := [hdrLen]lit.Lit{}
+	hdr[hdrSize] = lit.Lit(len(lits))
+
+	if learnt {
+		hdr[hdrFlags] = flagLearnt
+	}
+	a.mem = append(a.mem, hdr[:]...)
+	a.mem = append(a.mem, lits...)
+
+	return c
+}
+
+// free marks a clause as deleted, so its space is reclaimed by the next
+// garbage collection.
+func (a *clauseArena) free(c cref) {
+	a.mem[int(c)+hdrFlags] |= flagDeleted
+	a.wasted += hdrLen + a.size(c)
+}
+
+// size returns the number of literals in the clause.
+func (a *clauseArena) size(c cref) int {
+	return int(a.mem[int(c)+hdrSize])
+}
+
+// shrink drops all but the first n literals of the clause.
+func (a *clauseArena) shrink(c cref, n int) {
+	a.wasted += a.size(c) - n
+	a.mem[int(c)+hdrSize] = lit.Lit(n)
+}
+
+// lits returns the clause's literals, backed by the arena.
+func (a *clauseArena) lits(c cref) []lit.Lit {
+	start := int(c) + hdrLen
+	return a.mem[start : start+a.size(c) : start+a.size(c)]
+}
+
+// flag returns true if the clause has the flag set.
+func (a *clauseArena) flag(c cref, f lit.Lit) bool {
+	return a.mem[int(c)+hdrFlags]&f != 0
+}
+
+// setFlag sets or clears a flag of the clause.
+func (a *clauseArena) setFlag(c cref, f lit.Lit, on bool) {
+	if on {
+		a.mem[int(c)+hdrFlags] |= f
+	} else {
+		a.mem[int(c)+hdrFlags] &^= f
+	}
+}
+
+// tier returns the clause's tier.
+func (a *clauseArena) tier(c cref) int {
+	return int(a.mem[int(c)+hdrFlags] >> flagTierShift)
+}
+
+// setTier sets the clause's tier.
+func (a *clauseArena) setTier(c cref, t int) {
+	flags := a.mem[int(c)+hdrFlags] & (1<<flagTierShift - 1)
+	a.mem[int(c)+hdrFlags] = flags | lit.Lit(t)<<flagTierShift
+}
+
+// field returns one of the clause's integer header fields.
+func (a *clauseArena) field(c cref, f int) int {
+	return int(a.mem[int(c)+f])
+}
+
+// setField sets one of the clause's integer header fields.
+func (a *clauseArena) setField(c cref, f int, v int) {
+	a.mem[int(c)+f] = lit.Lit(v)
+}
+
+// activity returns the clause's activity.
+func (a *clauseArena) activity(c cref) float64 {
+	return float64(math.Float32frombits(uint32(a.mem[int(c)+hdrActivity])))
+}
+
+// setActivity sets the clause's activity.
+func (a *clauseArena) setActivity(c cref, v float64) {
+	a.mem[int(c)+hdrActivity] = lit.Lit(math.Float32bits(float32(v)))
+}
+
+// relocate copies the clause into another arena, unless it was already
+// copied, and returns its new reference. The old arena keeps a forwarding
+// reference in place of the clause's LBD.
+func (a *clauseArena) relocate(c cref, to *clauseArena) cref {
+	if a.flag(c, flagRelocated) {
+		return cref(a.mem[int(c)+hdrLBD])
+	}
+	n := cref(len(to.mem))
+	to.mem = append(to.mem, a.mem[int(c):int(c)+hdrLen+a.size(c)]...)
+
+	a.setFlag(c, flagRelocated, true)
+	a.mem[int(c)+hdrLBD] = lit.Lit(n)
+
+	return n
+}
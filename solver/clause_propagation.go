@@ -2,47 +2,46 @@ package solver
 
 import "github.com/ericr/saturday/lit"
 
-// propagate attempts to infer additional unit info and, if found, adds it to
-// the propagation queue.
-func (c *Clause) propagate(p lit.Lit) bool {
+// propagate attempts to infer additional unit info after p became true and,
+// if found, adds it to the propagation queue. It returns true if the clause
+// moved to another literal's watch list, and false on conflict.
+func (c Clause) propagate(p lit.Lit) (bool, bool) {
+	lits := c.lits()
+
 	// Make sure the false literal is lits[1].
-	if c.lits[0] == p.Not() {
-		c.lits[0], c.lits[1] = c.lits[1], p.Not()
+	if lits[0] == p.Not() {
+		lits[0], lits[1] = lits[1], p.Not()
 	}
-	// If 0th watch is true, then the clause is already satisfied. We just need
-	// to reinsert it into the watch list.
-	if c.solver.litValue(c.lits[0]).True() {
-		c.addToWatcher(p)
-
-		return true
+	// If 0th watch is true, then the clause is already satisfied.
+	if c.solver.litValue(lits[0]).True() {
+		return false, true
 	}
 	// Look for a new literal to watch and insert this clause into its watch list.
-	for i := 2; i < c.Len(); i++ {
-		if !c.solver.litValue(c.lits[i]).False() {
-			c.lits[1], c.lits[i] = c.lits[i], p.Not()
-			c.addToWatcher(c.lits[1].Not())
+	for i := 2; i < len(lits); i++ {
+		if !c.solver.litValue(lits[i]).False() {
+			lits[1], lits[i] = lits[i], p.Not()
+			c.addToWatcher(lits[1].Not())
 
-			return true
+			return true, true
 		}
 	}
 	// Clause is unit under assignment.
-	c.addToWatcher(p)
-
-	return c.solver.enqueue(c.lits[0], c)
+	return false, c.solver.enqueue(lits[0], clauseReason(c.ref))
 }
 
 // calcReason returns the reason p was propagated.
-func (c *Clause) calcReason(p lit.Lit) []lit.Lit {
+func (c Clause) calcReason(p lit.Lit) []lit.Lit {
 	outReason := []lit.Lit{}
 	offset := 1
 	if c.solver.litValue(p).Undef() {
 		offset = 0
 	}
-	for i := offset; i < c.Len(); i++ {
-		outReason = append(outReason, c.lits[i].Not())
+	lits := c.lits()
+	for i := offset; i < len(lits); i++ {
+		outReason = append(outReason, lits[i].Not())
 	}
-	if c.learnt {
-		c.solver.claBumpActivity(c)
+	if c.learnt() {
+		c.solver.claBumpActivity(c.ref)
 	}
 	return outReason
 }
@@ -1,20 +1,21 @@
 package solver
 
 // simplify attempts to simplify the clause.
-func (c *Clause) simplify() bool {
+func (c Clause) simplify() bool {
+	lits := c.lits()
 	j := 0
-	for i := 0; i < c.Len(); i++ {
+	for i := 0; i < len(lits); i++ {
 		// Constraint is already satisfied.
-		if c.solver.litValue(c.lits[i]).True() {
+		if c.solver.litValue(lits[i]).True() {
 			return true
 		}
 		// Don't copy false literals.
-		if c.solver.litValue(c.lits[i]).Undef() {
-			c.lits[j] = c.lits[i]
+		if c.solver.litValue(lits[i]).Undef() {
+			lits[j] = lits[i]
 			j++
 		}
 	}
-	c.lits = c.lits[:j]
+	c.solver.ca.shrink(c.ref, j)
 
 	return false
 }
@@ -51,7 +51,7 @@ func TestDetectClauseFalseLits(t *testing.T) {
 	addLits(s, lits)
 	s.assigns[1] = tribool.False
 
-	if _, c := newClause(s, lits, false); c.Len() != 2 {
+	if _, c := newClause(s, lits, false); s.clause(c).Len() != 2 {
 		t.Fatalf("Did not remove false literals")
 	}
 }
@@ -63,7 +63,7 @@ func TestDetectClauseDuplicates(t *testing.T) {
 	lits := []lit.Lit{lit.New(0, false), lit.New(0, false), lit.New(1, true)}
 	addLits(s, lits)
 
-	if _, c := newClause(s, lits, false); c.Len() != 2 {
+	if _, c := newClause(s, lits, false); s.clause(c).Len() != 2 {
 		t.Fatalf("Did not remove duplicates")
 	}
 }
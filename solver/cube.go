@@ -0,0 +1,197 @@
+package solver
+
+import (
+	"github.com/ericr/saturday/config"
+	"github.com/ericr/saturday/lit"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// lookaheadCandidates is the number of variables, those occurring most in
+// problem constraints, that are looked ahead on when picking a split.
+const lookaheadCandidates = 32
+
+// Cube splits the problem into cubes of up to depth decisions by lookahead.
+// Every model of the problem extends one of the cubes, and cubes proven
+// unsatisfiable by lookahead are left out, so an unsatisfiable problem may
+// have no cubes at all.
+func (s *Solver) Cube(depth int) [][]int {
+	cubes := [][]int{}
+
+	if !s.simplifyDB() {
+		return cubes
+	}
+	s.cube(depth, s.rankVars(), &cubes)
+	s.cancelUntil(0)
+
+	return cubes
+}
+
+// cube extends the current decisions into cubes of up to depth more
+// decisions.
+func (s *Solver) cube(depth int, ranked []int, cubes *[][]int) {
+	if depth == 0 || s.NAssigns() == s.NVars() {
+		*cubes = append(*cubes, s.decisionInts())
+		return
+	}
+	v, forced, refuted := s.lookahead(ranked)
+
+	switch {
+	case refuted:
+		return
+	case v < 0:
+		*cubes = append(*cubes, s.decisionInts())
+		return
+	case forced != lit.Undef:
+		// A failed literal's negation is implied, so it doesn't count towards
+		// the depth.
+		level := s.decisionLevel()
+		s.assume(forced)
+		s.propagate()
+		s.cube(depth, ranked, cubes)
+		s.cancelUntil(level)
+
+		return
+	}
+	for _, p := range []lit.Lit{lit.New(v, false), lit.New(v, true)} {
+		level := s.decisionLevel()
+
+		if s.assume(p) && s.propagate() == crefUndef {
+			s.cube(depth-1, ranked, cubes)
+		}
+		s.cancelUntil(level)
+	}
+}
+
+// lookahead picks the variable to split on, which is the candidate whose
+// assignments propagate the most in both polarities. If a candidate fails in
+// one polarity the other is returned as forced, and if it fails in both the
+// current decisions are refuted. It returns -1 if there are no candidates.
+func (s *Solver) lookahead(ranked []int) (int, lit.Lit, bool) {
+	best, bestScore := -1, -1
+	candidates := 0
+
+	for _, v := range ranked {
+		if candidates == lookaheadCandidates {
+			break
+		}
+		if !s.assigns[v].Undef() {
+			continue
+		}
+		candidates++
+
+		pos, posOk := s.probe(lit.New(v, false))
+		neg, negOk := s.probe(lit.New(v, true))
+
+		switch {
+		case !posOk && !negOk:
+			return v, lit.Undef, true
+		case !posOk:
+			return v, lit.New(v, true), false
+		case !negOk:
+			return v, lit.New(v, false), false
+		}
+		if score := (pos + 1) * (neg + 1); score > bestScore {
+			best, bestScore = v, score
+		}
+	}
+	return best, lit.Undef, false
+}
+
+// probe returns the number of assignments propagated by assuming p, and
+// false on conflict.
+func (s *Solver) probe(p lit.Lit) (int, bool) {
+	level := s.decisionLevel()
+	start := s.NAssigns()
+
+	ok := s.assume(p) && s.propagate() == crefUndef
+	n := s.NAssigns() - start
+	s.cancelUntil(level)
+
+	return n, ok
+}
+
+// rankVars returns the variables ordered by how often they occur in problem
+// constraints, most first.
+func (s *Solver) rankVars() []int {
+	occs := make([]int, s.NVars())
+	ranked := make([]int, s.NVars())
+
+	for _, c := range s.constrs {
+		for _, p := range s.ca.lits(c) {
+			occs[p.Index()]++
+		}
+	}
+	for v := range ranked {
+		ranked[v] = v
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return occs[ranked[i]] > occs[ranked[j]]
+	})
+	return ranked
+}
+
+// decisionInts returns the current decisions in terms of user-defined
+// variables.
+func (s *Solver) decisionInts() []int {
+	ps := make([]int, s.decisionLevel())
+
+	for i, start := range s.trailLim {
+		ps[i] = s.userInt(s.trail[start])
+	}
+	return ps
+}
+
+// Conquer solves the clauses under each of the cubes with n solvers in
+// parallel, each solving its share of cubes incrementally. It returns true and
+// a model as soon as a cube is satisfiable, or false once all cubes are shown
+// unsatisfiable.
+func Conquer(c *config.Config, clauses [][]int, cubes [][]int, n int) (bool, []int) {
+	queue := make(chan []int, len(cubes))
+	for _, cube := range cubes {
+		queue <- cube
+	}
+	close(queue)
+
+	solvers := make([]*Solver, n)
+	for i := range solvers {
+		solvers[i] = New(workerConfig(c, i))
+
+		for _, clause := range clauses {
+			solvers[i].AddClause(clause)
+		}
+	}
+	var model []int
+	found := atomic.Bool{}
+	once := sync.Once{}
+	wg := sync.WaitGroup{}
+
+	for _, s := range solvers {
+		wg.Add(1)
+
+		go func(s *Solver) {
+			defer wg.Done()
+
+			for cube := range queue {
+				if found.Load() {
+					return
+				}
+				if s.solve(cube).True() {
+					once.Do(func() {
+						model = s.Answer()
+						found.Store(true)
+
+						for _, other := range solvers {
+							other.Interrupt()
+						}
+					})
+					return
+				}
+			}
+		}(s)
+	}
+	wg.Wait()
+
+	return found.Load(), model
+}
@@ -0,0 +1,59 @@
+package solver
+
+import (
+	"github.com/ericr/saturday/config"
+	"io"
+	"log"
+	"testing"
+)
+
+func TestCube(t *testing.T) {
+	conf := config.New()
+	conf.Logger = log.New(io.Discard, "", 0)
+
+	for seed := int64(0); seed < 4; seed++ {
+		clauses := random3SAT(seed, 60, 4.26)
+		s := New(conf)
+
+		for _, c := range clauses {
+			s.AddClause(c)
+		}
+		cubes := s.Cube(4)
+
+		if len(cubes) > 16 {
+			t.Fatalf("TestCube() failed, got %d cubes", len(cubes))
+		}
+		want := s.Solve([]int{})
+		got, model := Conquer(conf, clauses, cubes, 2)
+
+		if got != want {
+			t.Fatalf("TestCube() failed, got: %v", got)
+		}
+		if !got {
+			continue
+		}
+		m := map[int]bool{}
+		for _, l := range model {
+			m[l] = true
+		}
+		for _, c := range clauses {
+			if !m[c[0]] && !m[c[1]] && !m[c[2]] {
+				t.Fatalf("TestCube() failed, clause not satisfied: %v", c)
+			}
+		}
+	}
+}
+
+func TestCubeUnsat(t *testing.T) {
+	conf := config.New()
+	conf.Logger = log.New(io.Discard, "", 0)
+	clauses := pigeonhole(5)
+	s := New(conf)
+
+	for _, c := range clauses {
+		s.AddClause(c)
+	}
+	if got, _ := Conquer(conf, clauses, s.Cube(3), 2); got {
+		t.Fatalf("TestCubeUnsat() failed: sat")
+	}
+}
@@ -0,0 +1,104 @@
+package solver
+
+import (
+	"github.com/ericr/saturday/lit"
+	"sync/atomic"
+)
+
+const (
+	// shareMaxLen is the length up to which learnt clauses are shared.
+	shareMaxLen = 4
+	// shareMaxLBD is the literal block distance up to which learnt clauses are
+	// shared regardless of their length.
+	shareMaxLBD = 2
+	// exchangeSize is the number of shared clauses kept by an exchange. Readers
+	// that fall further behind miss the oldest clauses.
+	exchangeSize = 1 << 12
+)
+
+// sharedClause is a learnt clause published to an exchange.
+type sharedClause struct {
+	// seq is the clause's position in the exchange's history.
+	seq uint64
+	// from is the id of the solver that learnt the clause.
+	from int
+	// lits holds the clause in terms of user-defined variables.
+	lits []int
+	lbd  int
+}
+
+// exchange is a lock-free ring buffer through which concurrent solvers share
+// learnt clauses. Each reader keeps its own cursor into the history.
+type exchange struct {
+	head  atomic.Uint64
+	slots [exchangeSize]atomic.Pointer[sharedClause]
+}
+
+// newExchange returns a new empty exchange.
+func newExchange() *exchange {
+	return &exchange{}
+}
+
+// publish adds a clause learnt by solver from to the exchange.
+func (e *exchange) publish(from int, lits []int, lbd int) {
+	seq := e.head.Add(1) - 1
+	e.slots[seq%exchangeSize].Store(&sharedClause{seq: seq, from: from, lits: lits, lbd: lbd})
+}
+
+// collect calls fn with every clause published since cursor by other solvers
+// than reader, returning the new cursor.
+func (e *exchange) collect(reader int, cursor uint64, fn func(*sharedClause) bool) uint64 {
+	head := e.head.Load()
+
+	if head-cursor > exchangeSize {
+		cursor = head - exchangeSize
+	}
+	for ; cursor < head; cursor++ {
+		c := e.slots[cursor%exchangeSize].Load()
+
+		// Skip slots that are still being written or were already overwritten.
+		if c == nil || c.seq != cursor || c.from == reader {
+			continue
+		}
+		if !fn(c) {
+			return cursor + 1
+		}
+	}
+	return cursor
+}
+
+// exportShared publishes a learnt clause to the solver's exchange.
+func (s *Solver) exportShared(lits []lit.Lit, lbd int) {
+	s.exchange.publish(s.id, s.userInts(lits), lbd)
+}
+
+// importShared adds the clauses published by other solvers since the last
+// import as learnt clauses. It must be called on the top level, and returns
+// false on conflict.
+func (s *Solver) importShared() bool {
+	ok := true
+
+	s.shareCursor = s.exchange.collect(s.id, s.shareCursor, func(sc *sharedClause) bool {
+		lits := make([]lit.Lit, 0, len(sc.lits))
+
+		for _, p := range sc.lits {
+			q := lit.NewFromInt(p)
+
+			if !s.hasUserVar(q.Var()) {
+				return true
+			}
+			lits = append(lits, lit.New(s.userVars[q.Var()], q.Sign()))
+		}
+		var c cref
+
+		if ok, c = newClause(s, lits, false); ok && c != crefUndef {
+			s.ca.setFlag(c, flagLearnt, true)
+			s.ca.setField(c, hdrLBD, sc.lbd)
+			s.ca.setField(c, hdrTouched, s.conflicts)
+			s.ca.setTier(c, tierFor(sc.lbd))
+			s.learnts = append(s.learnts, c)
+		}
+		return ok
+	})
+	return ok && s.propagate() == crefUndef
+}
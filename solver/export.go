@@ -0,0 +1,38 @@
+package solver
+
+import (
+	"github.com/ericr/saturday/encoding"
+	"io"
+)
+
+// Export writes the problem in the DIMACS format, in terms of user-defined
+// variables. It consists of the problem constraints and the units assigned at
+// the top level, followed by the learnt clauses when includeLearnts is set.
+// An empty clause is written when the problem is known to be unsatisfiable,
+// since conflicting clauses aren't kept.
+func (s *Solver) Export(w io.Writer, includeLearnts bool) error {
+	clauses := [][]int{}
+	units := map[int]bool{}
+
+	for _, c := range s.constrs {
+		clause := s.userInts(s.clause(c).lits())
+		if len(clause) == 1 {
+			units[clause[0]] = true
+		}
+		clauses = append(clauses, clause)
+	}
+	for _, p := range s.trail {
+		if u := s.userInt(p); s.level[p.Index()] == 0 && !units[u] {
+			clauses = append(clauses, []int{u})
+		}
+	}
+	if s.unsat {
+		clauses = append(clauses, []int{})
+	}
+	if includeLearnts {
+		for _, c := range s.learnts {
+			clauses = append(clauses, s.userInts(s.clause(c).lits()))
+		}
+	}
+	return encoding.WriteDimacs(w, clauses)
+}
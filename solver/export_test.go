@@ -0,0 +1,92 @@
+package solver
+
+import (
+	"bytes"
+	"github.com/ericr/saturday/config"
+	"github.com/ericr/saturday/encoding"
+	"io"
+	"log"
+	"testing"
+)
+
+func TestExport(t *testing.T) {
+	conf := config.New()
+	s := New(conf)
+	buf := bytes.Buffer{}
+
+	s.AddClause([]int{10, -20})
+	s.AddClause([]int{-30})
+
+	if err := s.Export(&buf, false); err != nil {
+		t.Fatalf("TestExport() failed, got: %v", err)
+	}
+	if want := "p cnf 30 2\n10 -20 0\n-30 0\n"; buf.String() != want {
+		t.Fatalf("TestExport() failed, got: %q", buf.String())
+	}
+}
+
+func TestExportUnsat(t *testing.T) {
+	conf := config.New()
+	s := New(conf)
+	buf := bytes.Buffer{}
+
+	s.AddClause([]int{1, 2})
+	s.AddClause([]int{-1})
+	s.AddClause([]int{1})
+
+	if err := s.Export(&buf, false); err != nil {
+		t.Fatalf("TestExportUnsat() failed, got: %v", err)
+	}
+	clauses, err := encoding.ParseDimacs(&buf)
+	if err != nil {
+		t.Fatalf("TestExportUnsat() failed, got: %v", err)
+	}
+	exported := New(conf)
+
+	for _, c := range clauses {
+		exported.AddClause(c)
+	}
+	if exported.Solve([]int{}) {
+		t.Fatalf("TestExportUnsat() failed, got: %v", clauses)
+	}
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	conf := config.New()
+	conf.Logger = log.New(io.Discard, "", 0)
+
+	for _, test := range []struct {
+		clauses [][]int
+		sat     bool
+	}{
+		{random3SAT(3, 120, 4.2), true},
+		{random3SAT(5, 120, 4.2), false},
+		{pigeonhole(6), false},
+	} {
+		s := New(conf)
+
+		for _, c := range test.clauses {
+			s.AddClause(c)
+		}
+		if s.Solve([]int{}) != test.sat {
+			t.Fatalf("TestExportRoundTrip() failed, want sat: %v", test.sat)
+		}
+		buf := bytes.Buffer{}
+
+		if err := s.Export(&buf, true); err != nil {
+			t.Fatalf("TestExportRoundTrip() failed, got: %v", err)
+		}
+		clauses, err := encoding.ParseDimacs(&buf)
+		if err != nil {
+			t.Fatalf("TestExportRoundTrip() failed, got: %v", err)
+		}
+		exported := New(conf)
+
+		for _, c := range clauses {
+			exported.AddClause(c)
+		}
+		if exported.Solve([]int{}) != test.sat {
+			t.Fatalf("TestExportRoundTrip() failed, want sat: %v", test.sat)
+		}
+	}
+}
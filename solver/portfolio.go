@@ -0,0 +1,144 @@
+package solver
+
+import (
+	"fmt"
+	"github.com/ericr/saturday/config"
+	"github.com/ericr/saturday/tribool"
+	"log"
+	"sync"
+)
+
+// portfolioVariants diversifies the solvers of a portfolio. The first solver
+// uses the config as given.
+var portfolioVariants = []func(c *config.Config){
+	func(c *config.Config) {},
+	func(c *config.Config) {
+		c.Branching = config.BranchingVMTF
+		c.Restarts = config.RestartsGlucose
+	},
+	func(c *config.Config) {
+		c.Branching = config.BranchingLRB
+		c.Restarts = config.RestartsLuby
+		c.Polarity = config.PolarityFalse
+	},
+	func(c *config.Config) {
+		c.Branching = config.BranchingVMTF
+		c.StableBranching = config.BranchingVSIDS
+		c.Restarts = config.RestartsGlucose
+	},
+	func(c *config.Config) {
+		c.Restarts = config.RestartsLuby
+		c.Polarity = config.PolarityRandom
+		c.Chrono = 0
+	},
+	func(c *config.Config) {
+		c.Branching = config.BranchingLRB
+		c.Restarts = config.RestartsGlucose
+		c.Rephase = false
+	},
+}
+
+// Portfolio runs several diversified solvers on the same problem in parallel,
+// sharing short learnt clauses between them, and answers with the first one
+// to finish.
+type Portfolio struct {
+	solvers []*Solver
+	// winner is the solver that answered the last call to Solve.
+	winner *Solver
+}
+
+// NewPortfolio returns a portfolio of n solvers diversified from c.
+func NewPortfolio(c *config.Config, n int) *Portfolio {
+	p := &Portfolio{}
+	e := newExchange()
+
+	for i := 0; i < n; i++ {
+		conf := workerConfig(c, i)
+		portfolioVariants[i%len(portfolioVariants)](conf)
+
+		// Solvers beyond the variants only differ by seed and random polarity.
+		if i >= len(portfolioVariants) {
+			conf.Polarity = config.PolarityRandom
+		}
+		s := New(conf)
+		s.exchange = e
+		s.id = i
+		p.solvers = append(p.solvers, s)
+	}
+	p.winner = p.solvers[0]
+
+	return p
+}
+
+// workerConfig returns a copy of c for the ith of several concurrent solvers,
+// seeded differently and logging with the solver's number as prefix.
+func workerConfig(c *config.Config, i int) *config.Config {
+	conf := c.Clone()
+	conf.Seed = c.Seed + int64(i)
+	conf.Logger = log.New(c.Logger.Writer(), fmt.Sprintf("[%d] ", i),
+		c.Logger.Flags())
+
+	return conf
+}
+
+// AddClause adds a new clause to every solver.
+func (p *Portfolio) AddClause(ps []int) bool {
+	ok := true
+
+	for _, s := range p.solvers {
+		ok = s.AddClause(ps) && ok
+	}
+	return ok
+}
+
+// Solve solves the SAT problem with every solver until one of them finishes,
+// returning true when satisfactory and false when unsatisfactory.
+func (p *Portfolio) Solve(ps []int) bool {
+	type result struct {
+		s      *Solver
+		status tribool.Tribool
+	}
+	results := make(chan result, len(p.solvers))
+	wg := sync.WaitGroup{}
+
+	for _, s := range p.solvers {
+		wg.Add(1)
+
+		go func(s *Solver) {
+			defer wg.Done()
+			s.status = s.solve(ps)
+			results <- result{s, s.status}
+		}(s)
+	}
+	var r result
+
+	for range p.solvers {
+		if r = <-results; !r.status.Undef() {
+			break
+		}
+	}
+	for _, s := range p.solvers {
+		if s != r.s {
+			s.Interrupt()
+		}
+	}
+	wg.Wait()
+	p.winner = r.s
+
+	// Clear interrupts that arrived after a solver had already finished.
+	for _, s := range p.solvers {
+		s.interrupted.Store(false)
+	}
+
+	return r.status.True()
+}
+
+// Answer returns the model found by the last call to Solve as CNF.
+func (p *Portfolio) Answer() []int {
+	return p.winner.Answer()
+}
+
+// Winner returns the solver that answered the last call to Solve.
+func (p *Portfolio) Winner() *Solver {
+	return p.winner
+}
@@ -0,0 +1,54 @@
+package solver
+
+import (
+	"github.com/ericr/saturday/config"
+	"io"
+	"log"
+	"testing"
+)
+
+func TestPortfolio(t *testing.T) {
+	conf := config.New()
+	conf.Logger = log.New(io.Discard, "", 0)
+
+	for seed := int64(0); seed < 4; seed++ {
+		clauses := random3SAT(seed, 80, 4.26)
+		s := New(conf)
+		p := NewPortfolio(conf, 4)
+
+		for _, c := range clauses {
+			s.AddClause(c)
+			p.AddClause(c)
+		}
+		want := s.Solve([]int{})
+
+		if got := p.Solve([]int{}); got != want {
+			t.Fatalf("TestPortfolio() failed, got: %v", got)
+		}
+		if !want {
+			continue
+		}
+		model := map[int]bool{}
+		for _, l := range p.Answer() {
+			model[l] = true
+		}
+		for _, c := range clauses {
+			if !model[c[0]] && !model[c[1]] && !model[c[2]] {
+				t.Fatalf("TestPortfolio() failed, clause not satisfied: %v", c)
+			}
+		}
+	}
+}
+
+func TestPortfolioUnsat(t *testing.T) {
+	conf := config.New()
+	conf.Logger = log.New(io.Discard, "", 0)
+	p := NewPortfolio(conf, 3)
+
+	for _, c := range pigeonhole(6) {
+		p.AddClause(c)
+	}
+	if p.Solve([]int{}) {
+		t.Fatalf("TestPortfolioUnsat() failed: sat")
+	}
+}
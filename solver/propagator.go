@@ -0,0 +1,226 @@
+package solver
+
+import (
+	"github.com/ericr/saturday/lit"
+	"github.com/ericr/saturday/tribool"
+	"sort"
+)
+
+// ExternalPropagator takes part in the search alongside unit propagation, in
+// the style of IPASIR-UP. Literals are given as in DIMACS.
+type ExternalPropagator interface {
+	// NotifyAssignment is called with newly assigned literals of observed
+	// variables. Literals kept by chronological backtracking are notified
+	// again after NotifyBacktrack.
+	NotifyAssignment(lits []int)
+	// NotifyNewDecisionLevel is called before each decision.
+	NotifyNewDecisionLevel()
+	// NotifyBacktrack is called after assignments above level were undone.
+	NotifyBacktrack(level int)
+	// Propagate returns a literal implied by the current assignment, or zero
+	// if there is none.
+	Propagate() int
+	// Reason returns the clause that implied a literal returned by Propagate.
+	// It contains the literal, and all of its other literals are false. It's
+	// only asked for when needed by conflict analysis.
+	Reason(p int) []int
+	// ExternalClause returns a clause to add to the problem, or nil if there
+	// is none.
+	ExternalClause() []int
+	// CheckModel returns true if the complete assignment is accepted.
+	// Rejecting it requires returning a clause it falsifies from
+	// ExternalClause, otherwise the search stops and Solver.Status is undef.
+	CheckModel(model []int) bool
+}
+
+// ConnectPropagator connects an external propagator to the solver.
+func (s *Solver) ConnectPropagator(p ExternalPropagator) {
+	s.propagator = p
+	s.notified = 0
+}
+
+// AddObservedVar makes the external propagator get notified of assignments
+// to v.
+func (s *Solver) AddObservedVar(v int) {
+	p := s.newVar(lit.NewFromInt(v))
+	s.observed[p.Index()] = true
+}
+
+// propagateAll propagates all enqueued facts, as well as literals propagated
+// and clauses added by the external propagator, if connected.
+func (s *Solver) propagateAll() cref {
+	for {
+		if confl := s.propagate(); confl != crefUndef || s.propagator == nil {
+			return confl
+		}
+		s.notifyAssignments()
+
+		if ps := s.propagator.ExternalClause(); ps != nil {
+			if confl := s.addExternalClause(ps); confl != crefUndef {
+				return confl
+			}
+			continue
+		}
+		p := s.propagator.Propagate()
+		if p == 0 {
+			return crefUndef
+		}
+		if confl := s.propagateExternal(p); confl != crefUndef {
+			return confl
+		}
+	}
+}
+
+// notifyAssignments notifies the external propagator of observed literals
+// assigned since the last notification.
+func (s *Solver) notifyAssignments() {
+	ps := []int{}
+
+	for _, p := range s.trail[s.notified:] {
+		if s.observed[p.Index()] {
+			ps = append(ps, s.userInt(p))
+		}
+	}
+	s.notified = s.NAssigns()
+
+	if len(ps) > 0 {
+		s.propagator.NotifyAssignment(ps)
+	}
+}
+
+// propagateExternal assigns a literal propagated by the external propagator,
+// returning its reason clause as conflict if it's false.
+func (s *Solver) propagateExternal(p int) cref {
+	q := s.newVar(lit.NewFromInt(p))
+
+	switch {
+	case s.litValue(q).Undef():
+		s.enqueue(q, externalReason)
+	case s.litValue(q).False():
+		return s.addExternalClause(s.propagator.Reason(p))
+	}
+	return crefUndef
+}
+
+// checkModel asks the external propagator to accept the complete assignment.
+func (s *Solver) checkModel() bool {
+	s.notifyAssignments()
+	model := make([]int, s.NVars())
+
+	for v := range model {
+		model[v] = s.userInt(lit.New(v, s.assigns[v].False()))
+	}
+	return s.propagator.CheckModel(model)
+}
+
+// reasonOf returns the antecedent of v, asking the external propagator for its
+// reason clause if needed.
+func (s *Solver) reasonOf(v int) antecedent {
+	if s.reason[v].external() {
+		p := lit.New(v, s.assigns[v].False())
+		lits := s.externalLits(s.propagator.Reason(s.userInt(p)))
+		c := s.attachClause(lits, true)
+
+		s.ca.setField(c, hdrLBD, s.computeLBD(lits))
+		s.ca.setTier(c, tierLocal)
+		s.reason[v] = clauseReason(c)
+	}
+	return s.reason[v]
+}
+
+// addExternalClause adds a clause from the external propagator during search,
+// backtracking as needed to propagate it. It returns the clause if it's
+// falsified.
+func (s *Solver) addExternalClause(ps []int) cref {
+	lits := s.externalLits(ps)
+
+	for _, p := range lits {
+		if containsLit(lits, p.Not()) {
+			// Tautologies are always satisfied.
+			return crefUndef
+		}
+	}
+	switch {
+	case len(lits) == 0 || s.litValue(lits[0]).False():
+		return s.attachClause(lits, false)
+	case len(lits) == 1:
+		c := s.attachClause(lits, false)
+		s.cancelUntil(s.rootLevel)
+
+		if !s.enqueue(lits[0], clauseReason(c)) {
+			return c
+		}
+	case s.litValue(lits[1]).False():
+		// The clause is unit, so make sure it's propagated at the level it
+		// became unit at.
+		level := s.level[lits[1].Index()]
+		if level < s.rootLevel {
+			level = s.rootLevel
+		}
+		if !s.litValue(lits[0]).True() || s.level[lits[0].Index()] > level {
+			s.cancelUntil(level)
+		}
+		c := s.attachClause(lits, false)
+		s.enqueue(lits[0], clauseReason(c))
+	default:
+		s.attachClause(lits, false)
+	}
+	return crefUndef
+}
+
+// externalLits returns the literals of an external clause without duplicates,
+// ordered by how suitable they are for being watched: true literals first,
+// then unassigned ones, then false ones from the highest level down.
+func (s *Solver) externalLits(ps []int) []lit.Lit {
+	lits := []lit.Lit{}
+
+	for _, p := range ps {
+		q := s.newVar(lit.NewFromInt(p))
+
+		if !containsLit(lits, q) {
+			lits = append(lits, q)
+		}
+	}
+	rank := func(p lit.Lit) int {
+		switch s.litValue(p) {
+		case tribool.True:
+			return -s.NVars() + s.level[p.Index()]
+		case tribool.Undef:
+			return 0
+		}
+		return s.NVars() - s.level[p.Index()]
+	}
+	sort.SliceStable(lits, func(i, j int) bool {
+		if rank(lits[i]) != rank(lits[j]) {
+			return rank(lits[i]) < rank(lits[j])
+		}
+		return lits[i] < lits[j]
+	})
+	return lits
+}
+
+// attachClause stores a clause, watching its first two literals.
+func (s *Solver) attachClause(lits []lit.Lit, learnt bool) cref {
+	c := s.clause(s.ca.alloc(lits, learnt))
+
+	if c.Len() > 1 {
+		c.addToWatcher(lits[0].Not())
+		c.addToWatcher(lits[1].Not())
+	}
+	if learnt {
+		s.learnts = append(s.learnts, c.ref)
+	} else {
+		s.constrs = append(s.constrs, c.ref)
+	}
+	return c.ref
+}
+
+// containsLit returns true if p is in lits.
+func containsLit(lits []lit.Lit, p lit.Lit) bool {
+	for _, q := range lits {
+		if q == p {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,205 @@
+package solver
+
+import (
+	"github.com/ericr/saturday/config"
+	"io"
+	"log"
+	"testing"
+)
+
+// hiddenClauses is an external propagator enforcing clauses the solver
+// doesn't know about.
+type hiddenClauses struct {
+	clauses [][]int
+	assigns map[int]bool
+	trail   []int
+	limits  []int
+	reasons map[int][]int
+}
+
+func newHiddenClauses(clauses [][]int) *hiddenClauses {
+	return &hiddenClauses{
+		clauses: clauses,
+		assigns: map[int]bool{},
+		reasons: map[int][]int{},
+	}
+}
+
+func (h *hiddenClauses) value(p int) (bool, bool) {
+	v := p
+	if v < 0 {
+		v = -v
+	}
+	b, ok := h.assigns[v]
+	return b == (p > 0), ok
+}
+
+func (h *hiddenClauses) NotifyAssignment(lits []int) {
+	for _, p := range lits {
+		if p < 0 {
+			h.assigns[-p] = false
+		} else {
+			h.assigns[p] = true
+		}
+		h.trail = append(h.trail, p)
+	}
+}
+
+func (h *hiddenClauses) NotifyNewDecisionLevel() {
+	h.limits = append(h.limits, len(h.trail))
+}
+
+func (h *hiddenClauses) NotifyBacktrack(level int) {
+	for _, p := range h.trail[h.limits[level]:] {
+		if p < 0 {
+			p = -p
+		}
+		delete(h.assigns, p)
+	}
+	h.trail = h.trail[:h.limits[level]]
+	h.limits = h.limits[:level]
+}
+
+// unit returns the unassigned literal of a clause with all others false, or
+// zero, and whether the clause is falsified.
+func (h *hiddenClauses) unit(clause []int) (int, bool) {
+	unit := 0
+
+	for _, p := range clause {
+		b, ok := h.value(p)
+
+		switch {
+		case ok && b:
+			return 0, false
+		case !ok && unit != 0:
+			return 0, false
+		case !ok:
+			unit = p
+		}
+	}
+	return unit, unit == 0
+}
+
+func (h *hiddenClauses) Propagate() int {
+	for _, c := range h.clauses {
+		if p, _ := h.unit(c); p != 0 {
+			h.reasons[p] = c
+			return p
+		}
+	}
+	return 0
+}
+
+func (h *hiddenClauses) Reason(p int) []int {
+	return h.reasons[p]
+}
+
+func (h *hiddenClauses) ExternalClause() []int {
+	for _, c := range h.clauses {
+		if _, falsified := h.unit(c); falsified {
+			return c
+		}
+	}
+	return nil
+}
+
+func (h *hiddenClauses) CheckModel(model []int) bool {
+	return h.ExternalClause() == nil
+}
+
+func TestExternalPropagator(t *testing.T) {
+	for _, chrono := range []int{0, 1, 100} {
+		conf := config.New()
+		conf.Logger = log.New(io.Discard, "", 0)
+		conf.Chrono = chrono
+
+		for seed := int64(0); seed < 20; seed++ {
+			clauses := random3SAT(seed, 40, 4.26)
+			full := New(conf)
+			s := New(conf)
+			hidden := [][]int{}
+
+			for i, c := range clauses {
+				full.AddClause(c)
+
+				if i%2 == 0 {
+					s.AddClause(c)
+				} else {
+					hidden = append(hidden, c)
+				}
+			}
+			s.ConnectPropagator(newHiddenClauses(hidden))
+
+			for v := 1; v <= 40; v++ {
+				s.AddObservedVar(v)
+			}
+			want := full.Solve([]int{})
+
+			if got := s.Solve([]int{}); got != want {
+				t.Fatalf("TestExternalPropagator() failed, got: %v", got)
+			}
+			if !want {
+				continue
+			}
+			model := map[int]bool{}
+			for _, p := range s.Answer() {
+				model[p] = true
+			}
+			for _, c := range clauses {
+				if !model[c[0]] && !model[c[1]] && !model[c[2]] {
+					t.Fatalf("TestExternalPropagator() failed, clause not satisfied: %v", c)
+				}
+			}
+		}
+	}
+}
+
+// rejectAll is an external propagator rejecting every model, with a clause
+// given by ExternalClause once per rejection.
+type rejectAll struct {
+	clause   []int
+	rejected bool
+}
+
+func (r *rejectAll) NotifyAssignment(lits []int) {}
+func (r *rejectAll) NotifyNewDecisionLevel()     {}
+func (r *rejectAll) NotifyBacktrack(level int)   {}
+func (r *rejectAll) Propagate() int              { return 0 }
+func (r *rejectAll) Reason(p int) []int          { return nil }
+
+func (r *rejectAll) ExternalClause() []int {
+	if !r.rejected {
+		return nil
+	}
+	r.rejected = false
+	return r.clause
+}
+
+func (r *rejectAll) CheckModel(model []int) bool {
+	r.rejected = true
+	return false
+}
+
+func TestExternalPropagatorRejection(t *testing.T) {
+	conf := config.New()
+	conf.Logger = log.New(io.Discard, "", 0)
+
+	// Neither a missing clause nor a tautology refutes the model.
+	for _, clause := range [][]int{nil, {1, -1}} {
+		s := New(conf)
+		s.AddClause([]int{1, 2})
+		s.ConnectPropagator(&rejectAll{clause: clause})
+
+		if s.Solve([]int{}) || !s.Status().Undef() {
+			t.Fatalf("TestExternalPropagatorRejection() failed on %v, got: %v", clause, s.Status())
+		}
+		// The solver can be used again once the propagator is replaced.
+		s.ConnectPropagator(newHiddenClauses([][]int{{-1}}))
+		s.AddObservedVar(1)
+		s.AddObservedVar(2)
+
+		if !s.Solve([]int{}) {
+			t.Fatalf("TestExternalPropagatorRejection() failed on %v, got: unsat", clause)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package solver
+
+import (
+	"github.com/ericr/saturday/config"
+	"math"
+)
+
+// RestartPolicy decides when the search should be restarted.
+type RestartPolicy interface {
+	// Start is called at the beginning of each search, i.e. after every
+	// restart.
+	Start()
+	// Conflict is called after each conflict with the literal block distance of
+	// the clause that was learnt.
+	Conflict(lbd int)
+	// Restart returns true when the current search should be restarted.
+	Restart() bool
+}
+
+// newRestartPolicy returns the restart policy named by the config, or nil if
+// the name is unknown.
+func newRestartPolicy(c *config.Config) RestartPolicy {
+	switch c.Restarts {
+	case config.RestartsGeometric:
+		return NewGeometricRestarts(100, 2)
+	case config.RestartsLuby:
+		return NewLubyRestarts(100)
+	case config.RestartsGlucose:
+		return NewGlucoseRestarts(50, 0.8)
+	}
+	return nil
+}
+
+// GeometricRestarts restarts after a number of conflicts that grows
+// geometrically with every restart.
+type GeometricRestarts struct {
+	start     float64
+	base      float64
+	restarts  int
+	conflicts int
+	limit     int
+}
+
+// NewGeometricRestarts returns a policy that restarts after start*base^n
+// conflicts, where n is the number of restarts so far.
+func NewGeometricRestarts(start, base float64) *GeometricRestarts {
+	return &GeometricRestarts{start: start, base: base}
+}
+
+// Start implements the RestartPolicy interface.
+func (r *GeometricRestarts) Start() {
+	r.limit = int(r.start * math.Pow(r.base, float64(r.restarts)))
+	r.conflicts = 0
+	r.restarts++
+}
+
+// Conflict implements the RestartPolicy interface.
+func (r *GeometricRestarts) Conflict(lbd int) {
+	r.conflicts++
+}
+
+// Restart implements the RestartPolicy interface.
+func (r *GeometricRestarts) Restart() bool {
+	return r.conflicts >= r.limit
+}
+
+// LubyRestarts restarts after a number of conflicts following the Luby
+// sequence (1, 1, 2, 1, 1, 2, 4, ...) scaled by a unit.
+type LubyRestarts struct {
+	unit      int
+	restarts  int
+	conflicts int
+	limit     int
+}
+
+// NewLubyRestarts returns a policy that restarts after unit*luby(n)
+// conflicts, where n is the number of restarts so far.
+func NewLubyRestarts(unit int) *LubyRestarts {
+	return &LubyRestarts{unit: unit}
+}
+
+// Start implements the RestartPolicy interface.
+func (r *LubyRestarts) Start() {
+	r.limit = r.unit * luby(r.restarts)
+	r.conflicts = 0
+	r.restarts++
+}
+
+// Conflict implements the RestartPolicy interface.
+func (r *LubyRestarts) Conflict(lbd int) {
+	r.conflicts++
+}
+
+// Restart implements the RestartPolicy interface.
+func (r *LubyRestarts) Restart() bool {
+	return r.conflicts >= r.limit
+}
+
+// luby returns the ith (0-indexed) element of the Luby sequence.
+func luby(i int) int {
+	// Find the finite subsequence containing i, and its size.
+	size, seq := 1, 0
+	for size < i+1 {
+		seq++
+		size = 2*size + 1
+	}
+	for size-1 != i {
+		size = (size - 1) >> 1
+		seq--
+		i = i % size
+	}
+	return 1 << uint(seq)
+}
+
+// GlucoseRestarts restarts when the literal block distance of recently learnt
+// clauses is high compared to the average over the whole search, as done by
+// Glucose.
+type GlucoseRestarts struct {
+	// window is the number of recent conflicts to average over.
+	window int
+	// margin scales the recent average before comparing it to the total.
+	margin float64
+	// recent is a circular buffer of the most recent LBDs.
+	recent    []int
+	recentSum int
+	next      int
+	totalSum  float64
+	total     int
+}
+
+// NewGlucoseRestarts returns a policy that restarts when the average LBD of
+// the last window conflicts, scaled by margin, exceeds the global average.
+func NewGlucoseRestarts(window int, margin float64) *GlucoseRestarts {
+	return &GlucoseRestarts{
+		window: window,
+		margin: margin,
+		recent: make([]int, 0, window),
+	}
+}
+
+// Start implements the RestartPolicy interface.
+func (r *GlucoseRestarts) Start() {
+	r.recent = r.recent[:0]
+	r.recentSum = 0
+	r.next = 0
+}
+
+// Conflict implements the RestartPolicy interface.
+func (r *GlucoseRestarts) Conflict(lbd int) {
+	r.totalSum += float64(lbd)
+	r.total++
+
+	if len(r.recent) < r.window {
+		r.recent = append(r.recent, lbd)
+	} else {
+		r.recentSum -= r.recent[r.next]
+		r.recent[r.next] = lbd
+		r.next = (r.next + 1) % r.window
+	}
+	r.recentSum += lbd
+}
+
+// Restart implements the RestartPolicy interface.
+func (r *GlucoseRestarts) Restart() bool {
+	if len(r.recent) < r.window {
+		return false
+	}
+	recentAvg := float64(r.recentSum) / float64(r.window)
+	totalAvg := r.totalSum / float64(r.total)
+
+	return recentAvg*r.margin > totalAvg
+}
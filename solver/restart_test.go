@@ -0,0 +1,34 @@
+package solver
+
+import "testing"
+
+func TestLuby(t *testing.T) {
+	expected := []int{1, 1, 2, 1, 1, 2, 4, 1, 1, 2, 1, 1, 2, 4, 8}
+
+	for i, e := range expected {
+		if l := luby(i); l != e {
+			t.Fatalf("TestLuby() failed at %d, got: %d", i, l)
+		}
+	}
+}
+
+func TestGlucoseRestarts(t *testing.T) {
+	r := NewGlucoseRestarts(3, 0.8)
+	r.Start()
+
+	for i := 0; i < 10; i++ {
+		r.Conflict(2)
+	}
+	if r.Restart() {
+		t.Fatalf("Restarted with steady LBD")
+	}
+	for i := 0; i < 3; i++ {
+		r.Conflict(10)
+	}
+	if !r.Restart() {
+		t.Fatalf("Did not restart with rising LBD")
+	}
+	if r.Start(); r.Restart() {
+		t.Fatalf("Restarted before window filled")
+	}
+}
@@ -7,8 +7,9 @@ import (
 	"github.com/ericr/saturday/order"
 	"github.com/ericr/saturday/tribool"
 	"log"
-	"math"
+	"math/rand"
 	"sort"
+	"sync/atomic"
 )
 
 const (
@@ -22,22 +23,27 @@ type Solver struct {
 	config *config.Config
 	// logger is the solver's logger
 	logger *log.Logger
+	// rand is the solver's source of randomness, seeded by the config.
+	rand *rand.Rand
 
 	// Model Database Fields
 
-	// userVars keeps a map of user-defined variables to internal variables.
+	// userVars maps user-defined variables to internal variables. It's a map
+	// rather than a slice since user-defined variables may be sparse.
 	userVars map[int]int
-	// internalVars keeps a map of internal variables to user-defined variables.
-	internalVars map[int]int
+	// internalVars maps internal variables to user-defined variables.
+	internalVars []int
 	// model stores the most recently discovered model.
 	model map[int]bool
 
 	// Constraint Database Fields
 
 	// constrs is a list of problem constraints.
-	constrs []*Clause
+	constrs []cref
 	// learnts is a list of learnt clauses.
-	learnts []*Clause
+	learnts []cref
+	// ca stores the clauses referenced by constrs and learnts.
+	ca *clauseArena
 	// claInc is the clause activity increment.
 	claInc float64
 	// claDeacy is the decay factor for clause activity.
@@ -45,21 +51,26 @@ type Solver struct {
 
 	// Variable Order Fields
 	//
-	// activity is a heuristic measurement of the activity of a variable.
-	activity []float64
-	// varInc is the variable activity increment.
-	varInc float64
-	// varDecay is the decay factor for variable activity.
-	varDecay float64
 	// order keeps track of dynamic variable ordering.
-	order *order.Order
+	order order.Order
+	// orders contains the focused mode order, followed by the stable mode
+	// order when mode switching is enabled.
+	orders []order.Order
+	// stable is true when the solver is in stable mode.
+	stable bool
+	// nextModeSwitch is the conflict count at which the mode next switches.
+	nextModeSwitch int
+	// modeSwitches keeps track of how many times the mode has switched.
+	modeSwitches int
 
 	// Propagation Fields
 
-	// watches contains each literal and a list of constraints watching it.
-	watches map[lit.Lit][]*Clause
-	// propQ is the propagation queue.
-	propQ *lit.Queue
+	// watches contains a list of constraints watching each literal, indexed by
+	// the literal.
+	watches [][]watcher
+	// qhead is the index of the next literal on the trail to propagate. The
+	// trail from qhead onward serves as the propagation queue.
+	qhead int
 
 	// Assignment Fields
 
@@ -70,35 +81,91 @@ type Solver struct {
 	// trailLim is a list of separator indices for different decision levels in
 	// the trail.
 	trailLim []int
-	// reason is a list of each variable's constraint that implied its value.
-	reason []*Clause
+	// reason is a list of each variable's antecedent that implied its value.
+	reason []antecedent
 	// level is a list of each variable's decision level at which it was assigned.
 	level []int
 	// rootLevel separates incremental and search assumptions.
 	rootLevel int
+	// unsat is true once the problem was found unsatisfiable regardless of
+	// assumptions.
+	unsat bool
+	// failed contains the assumptions refuted by the last call to Solve.
+	failed []int
+	// status is the result of the last call to Solve.
+	status tribool.Tribool
+
+	// Phase Fields
+
+	// phases contains each variable's saved phase, i.e. its last value.
+	phases []bool
+	// userPhases contains each variable's polarity set by SetPolarity().
+	userPhases []tribool.Tribool
+	// targetPhases contains the assignment of the largest conflict-free trail
+	// since the last rephase.
+	targetPhases []tribool.Tribool
+	// targetLen is the size of the trail targetPhases was taken from.
+	targetLen int
+	// bestPhases contains the assignment of the largest conflict-free trail
+	// since the last rephase to the best phases.
+	bestPhases []tribool.Tribool
+	// bestLen is the size of the trail bestPhases was taken from.
+	bestLen int
+	// nextRephase is the conflict count at which rephase() next gets called.
+	nextRephase int
+	// rephases keeps track of how many times rephase() has been called.
+	rephases int
+	// walkPropagations is the propagation count at the last walk().
+	walkPropagations int
+
+	// Clause Database Reduction Fields
+
+	// nextReduceDB is the conflict count at which reduceDB() next gets called.
+	nextReduceDB int
+	// reduceDBFirst is the number of conflicts before the first reduceDB().
+	reduceDBFirst int
+	// reduceDBInc is the amount the interval between reduceDB() calls grows by.
+	reduceDBInc int
+	// reduceDBs keeps track of how many times reduceDB() has been called.
+	reduceDBs int
+	// nextTierCheck is the conflict count at which unused tier 2 clauses next
+	// get demoted.
+	nextTierCheck int
+	// lbdStamps holds, for each decision level, the last stamp used when
+	// computing a clause's literal block distance.
+	lbdStamps []int
+	// lbdStamp is the current stamp for computing literal block distance.
+	lbdStamp int
 
 	// Algorithmic Restarts Fields
 
-	// maxLearnts is the maximum number of learnt clauses before reduceDB() gets
-	// called.
-	maxLearnts float64
-	// maxLearntsGrowth is the growth factor for maxLearnts.
-	maxLearntsGrowth float64
-	// maxLearntsCtr is a counter that controls how often maxLearnts gets
-	// increased.
-	maxLearntsCtr int
-	// maxLearntsCtrInc is the amount to increase maxLearntsCtr once it reaches
-	// zero.
-	maxLearntsCtrInc float64
-	// maxLearntsCtrIncGrowth is the growth factor for maxLearntsCtrInc.
-	maxLearntsCtrIncGrowth float64
-	// maxConflicts is the maximum number of conflicts before a restart occurs.
-	maxConflicts float64
-	// maxConflictsGrowthStart is the starting constant for maxConflicts's
-	// growth.
-	maxConflictsGrowthStart float64
-	// maxConflictsGrowth is the base of the growth factor for maxConflicts.
-	maxConflictsGrowthBase float64
+	// restartPolicy decides when the search restarts.
+	restartPolicy RestartPolicy
+
+	// Clause Sharing Fields
+
+	// exchange shares learnt clauses with concurrent solvers, if any.
+	exchange *exchange
+	// id identifies the solver's clauses in the exchange.
+	id int
+	// shareCursor is the position in the exchange up to which shared clauses
+	// have been imported.
+	shareCursor uint64
+	// interrupted is set to stop the search from another goroutine.
+	interrupted atomic.Bool
+
+	// External Propagation Fields
+
+	// propagator takes part in the search, if connected.
+	propagator ExternalPropagator
+	// observed contains whether the propagator observes each variable.
+	observed []bool
+	// notified is the index of the next trail literal to notify the
+	// propagator of.
+	notified int
+
+	// tracer is notified of search events, if set.
+	tracer Tracer
 
 	// Stats Fields
 
@@ -117,83 +184,147 @@ func New(c *config.Config) *Solver {
 	s := &Solver{
 		config:       c,
 		logger:       c.Logger,
+		rand:         rand.New(rand.NewSource(c.Seed)),
 		userVars:     map[int]int{},
-		internalVars: map[int]int{},
+		internalVars: []int{},
 		model:        map[int]bool{},
-		learnts:      []*Clause{},
-		activity:     []float64{},
-		watches:      map[lit.Lit][]*Clause{},
-		propQ:        lit.NewQueue(),
+		learnts:      []cref{},
+		ca:           newClauseArena(1024),
+		watches:      [][]watcher{},
 		assigns:      []tribool.Tribool{},
 		trail:        []lit.Lit{},
 		trailLim:     []int{},
-		reason:       []*Clause{},
+		reason:       []antecedent{},
 		level:        []int{},
+		lbdStamps:    []int{0},
+		phases:       []bool{},
+		userPhases:   []tribool.Tribool{},
+		targetPhases: []tribool.Tribool{},
+		bestPhases:   []tribool.Tribool{},
+		observed:     []bool{},
 	}
-	s.order = order.New(&s.assigns, &s.activity)
+	s.orders = []order.Order{s.newOrder(c.Branching)}
 
+	if c.StableBranching != "" {
+		s.orders = append(s.orders, s.newOrder(c.StableBranching))
+		s.nextModeSwitch = modeSwitchInterval
+	}
+	s.order = s.orders[0]
+
+	if s.restartPolicy = newRestartPolicy(c); s.restartPolicy == nil {
+		s.logger.Printf("Unknown restart policy %q, using %q", c.Restarts,
+			config.RestartsGeometric)
+		s.restartPolicy = NewGeometricRestarts(100, 2)
+	}
 	return s
 }
 
+// SetPolarity sets the value the solver tries first when deciding on variable
+// v, overriding any saved or target phase.
+func (s *Solver) SetPolarity(v int, b bool) {
+	p := s.newVar(lit.NewFromInt(v))
+	s.userPhases[p.Index()] = tribool.NewFromBool(b)
+}
+
+// SetRestartPolicy replaces the policy deciding when the search restarts.
+func (s *Solver) SetRestartPolicy(p RestartPolicy) {
+	s.restartPolicy = p
+}
+
 // Version returns the version of the solver.
 func Version() string {
 	return fmt.Sprintf("%d.%d", VersionMajor, VersionMinor)
 }
 
+// Interrupt stops a running Solve from another goroutine, which then returns
+// false with an undefined Status.
+func (s *Solver) Interrupt() {
+	s.interrupted.Store(true)
+}
+
 // Solve accepts a list of constraints and solves the SAT problem, returning
-// true when satisfactory and false when unsatisfactory.
+// true when satisfactory and false otherwise. Status tells whether it's
+// unsatisfactory or the search stopped without a result.
 func (s *Solver) Solve(ps []int) bool {
+	s.status = s.solve(ps)
+	return s.status.True()
+}
+
+// Status returns the result of the last call to Solve: true when
+// satisfactory, false when unsatisfactory, and undef when the search was
+// interrupted or an external propagator rejected a model without a clause.
+func (s *Solver) Status() tribool.Tribool {
+	return s.status
+}
+
+// solve solves the SAT problem under the assumptions ps, returning undef when
+// interrupted.
+func (s *Solver) solve(ps []int) tribool.Tribool {
+	defer s.interrupted.Store(false)
+
 	assumps := []lit.Lit{}
-	params := searchParams{s.config.VarDecay, s.config.ClaDecay}
+	params := searchParams{s.config.ClaDecay}
 	status := tribool.Undef
+	s.failed = []int{}
 
 	// Set values for activity algorithm.
-	s.varInc = 1.0
 	s.claInc = 1.0
 
-	// Set values for the maxLearnts growth algorithm.
-	s.maxLearnts = float64(s.NConstrs()) / 3.0
-	s.maxLearntsGrowth = 1.1
-	s.maxLearntsCtrInc = 100.0
-	s.maxLearntsCtr = int(s.maxLearntsCtrInc)
-	s.maxLearntsCtrIncGrowth = 1.5
+	// Set values for the reduceDB schedule.
+	s.reduceDBFirst = 2000
+	s.reduceDBInc = 300
+	s.nextReduceDB = s.conflicts + s.reduceDBFirst
+	s.nextTierCheck = s.conflicts + tierCheckInterval
 
-	// Set values for the maxConflicts growth algorithm.
-	s.maxConflictsGrowthStart = 100.0
-	s.maxConflictsGrowthBase = 2.0
+	// Set values for the rephase schedule.
+	s.nextRephase = s.conflicts + rephaseInterval*(s.rephases+1)
 
-	if !s.simplifyDB() {
-		return false
+	if s.unsat || !s.simplifyDB() {
+		s.unsat = true
+		return tribool.False
 	}
 	s.order.Init()
 
+	// Assumptions on unknown variables introduce them.
 	for _, p := range ps {
-		assump := lit.NewFromInt(p)
+		assumps = append(assumps, s.newVar(lit.NewFromInt(p)))
+	}
+	s.rootLevel = 0
 
-		if _, ok := s.userVars[assump.Var()]; !ok {
-			// Illegal assumption.
-			return false
+	for _, p := range assumps {
+		if !s.assume(p) {
+			s.analyzeFinal([]lit.Lit{p.Not()})
+			s.failed = append(s.failed, s.userInt(p))
+			s.cancelUntil(0)
+
+			return tribool.False
 		}
-		assumps = append(assumps, s.newVar(assump))
-	}
-	for i := 0; i < len(assumps); i++ {
-		if !s.assume(assumps[i]) || s.propagate() != nil {
+		if confl := s.propagate(); confl != crefUndef {
+			s.analyzeFinal(s.clause(confl).calcReason(lit.Undef))
+			s.unsat = len(s.failed) == 0
 			s.cancelUntil(0)
 
-			return false
+			return tribool.False
 		}
 	}
 	s.rootLevel = s.decisionLevel()
 
-	for status.Undef() {
-		s.maxConflicts = s.maxConflictsGrowthStart *
-			math.Pow(s.maxConflictsGrowthBase, float64(s.restarts))
+	for status.Undef() && !s.interrupted.Load() {
+		if len(s.orders) > 1 && s.conflicts >= s.nextModeSwitch {
+			s.switchMode()
+		}
+		s.restartPolicy.Start()
 		status = s.search(params)
 		s.restarts++
 	}
+	// A conflict independent of the assumptions is final, and wouldn't be
+	// found again since the top level propagations are done.
+	if status.False() && len(s.failed) == 0 {
+		s.unsat = true
+	}
 	s.cancelUntil(0)
 
-	return status.True()
+	return status
 }
 
 func (s *Solver) SolveMany(ps []int, mCount uint) [][]int {
@@ -204,12 +335,13 @@ func (s *Solver) SolveMany(ps []int, mCount uint) [][]int {
 			s.logger.Printf("Found %d/%d models", i+1, mCount)
 
 			models = append(models, s.Answer())
-			constrs := s.constrs
+			prev := s
 
 			s = New(s.config)
+			s.tracer = prev.tracer
 
-			for _, c := range constrs {
-				s.AddClause(c.asInts())
+			for _, c := range prev.constrs {
+				s.AddClause(prev.clause(c).asInts())
 			}
 			for _, model := range models {
 				newConstr := []int{}
@@ -227,6 +359,13 @@ func (s *Solver) SolveMany(ps []int, mCount uint) [][]int {
 	return models
 }
 
+// FailedAssumptions returns the subset of the assumptions given to the last
+// call to Solve that was used to refute them, when it returned false. It's
+// empty when the problem is unsatisfiable regardless of the assumptions.
+func (s *Solver) FailedAssumptions() []int {
+	return s.failed
+}
+
 // AddClause adds a new clause to the solver.
 func (s *Solver) AddClause(ps []int) bool {
 	lits := []lit.Lit{}
@@ -235,7 +374,9 @@ func (s *Solver) AddClause(ps []int) bool {
 		lits = append(lits, s.newVar(lit.NewFromInt(p)))
 	}
 	success, c := newClause(s, lits, false)
-	if success {
+	if !success {
+		s.unsat = true
+	} else if c != crefUndef {
 		s.constrs = append(s.constrs, c)
 	}
 	return success
@@ -308,20 +449,50 @@ func (s *Solver) NDecisions() int {
 
 // newVar adds a new variable to the solver, referenced thereafter by its index.
 func (s *Solver) newVar(p lit.Lit) lit.Lit {
-	if _, ok := s.userVars[p.Var()]; !ok {
+	if !s.hasUserVar(p.Var()) {
 		s.userVars[p.Var()] = s.NVars()
-		s.internalVars[s.NVars()] = p.Var()
-		s.watches[lit.New(s.NVars()+1, false)] = []*Clause{}
-		s.watches[lit.New(s.NVars()+1, true)] = []*Clause{}
-		s.reason = append(s.reason, nil)
+		s.internalVars = append(s.internalVars, p.Var())
+		s.watches = append(s.watches, []watcher{}, []watcher{})
+		s.reason = append(s.reason, noReason)
 		s.assigns = append(s.assigns, tribool.Undef)
 		s.level = append(s.level, -1)
-		s.activity = append(s.activity, float64(0))
-		s.order.NewVar()
+		s.lbdStamps = append(s.lbdStamps, 0)
+		s.phases = append(s.phases, s.defaultPhase())
+		s.userPhases = append(s.userPhases, tribool.Undef)
+		s.targetPhases = append(s.targetPhases, tribool.Undef)
+		s.bestPhases = append(s.bestPhases, tribool.Undef)
+		s.observed = append(s.observed, false)
+		for _, o := range s.orders {
+			o.NewVar()
+		}
 	}
 	return lit.New(s.userVars[p.Var()], p.Sign())
 }
 
+// hasUserVar returns true if the user-defined variable v is known.
+func (s *Solver) hasUserVar(v int) bool {
+	_, ok := s.userVars[v]
+	return ok
+}
+
+// userInt returns p in terms of its user-defined variable.
+func (s *Solver) userInt(p lit.Lit) int {
+	if p.Sign() {
+		return -s.internalVars[p.Index()]
+	}
+	return s.internalVars[p.Index()]
+}
+
+// userInts returns lits in terms of their user-defined variables.
+func (s *Solver) userInts(lits []lit.Lit) []int {
+	ps := make([]int, len(lits))
+
+	for i, p := range lits {
+		ps[i] = s.userInt(p)
+	}
+	return ps
+}
+
 // litValue returns p's value.
 func (s *Solver) litValue(p lit.Lit) tribool.Tribool {
 	if p == lit.Undef {
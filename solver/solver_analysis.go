@@ -1,18 +1,34 @@
 package solver
 
-import "github.com/ericr/saturday/lit"
+import (
+	"github.com/ericr/saturday/config"
+	"github.com/ericr/saturday/lit"
+)
 
 // analyze performs analysis on a conflict, returning the reason and the level
 // to backtrack to (highest level in conflict clause).
-func (s *Solver) analyze(confl *Clause) ([]lit.Lit, int) {
+func (s *Solver) analyze(c cref) ([]lit.Lit, int) {
+	confl := clauseReason(c)
 	seen := make([]bool, s.NVars())
 	p := lit.Undef
 	learnts := []lit.Lit{lit.Undef}
 	counter := 0
 	btLevel := 0
+	idx := s.NAssigns() - 1
+	analyzed := []int{}
 
 	for {
-		pReason := confl.calcReason(p)
+		var pReason []lit.Lit
+
+		if confl.clause != crefUndef {
+			c := s.clause(confl.clause)
+			if c.learnt() {
+				s.bumpLBD(confl.clause)
+			}
+			pReason = c.calcReason(p)
+		} else {
+			pReason = []lit.Lit{confl.other.Not()}
+		}
 		// Trace reason for p.
 		for j := 0; j < len(pReason); j++ {
 			q := pReason[j]
@@ -21,6 +37,10 @@ func (s *Solver) analyze(confl *Clause) ([]lit.Lit, int) {
 				seen[q.Index()] = true
 				level := s.level[q.Index()]
 
+				if level > 0 {
+					analyzed = append(analyzed, q.Index())
+				}
+
 				switch {
 				case level == s.decisionLevel():
 					counter++
@@ -36,12 +56,13 @@ func (s *Solver) analyze(confl *Clause) ([]lit.Lit, int) {
 		}
 		// Select the next literal to look at.
 		for {
-			p = s.trail[s.NAssigns()-1]
-			confl = s.reason[p.Index()]
-
-			s.undoOne()
+			p = s.trail[idx]
+			confl = s.reasonOf(p.Index())
+			idx--
 
-			if seen[p.Index()] {
+			// Literals from lower levels may be interleaved after chronological
+			// backtracking.
+			if seen[p.Index()] && s.level[p.Index()] == s.decisionLevel() {
 				break
 			}
 		}
@@ -52,15 +73,184 @@ func (s *Solver) analyze(confl *Clause) ([]lit.Lit, int) {
 	}
 	learnts[0] = p.Not()
 
+	if s.config.CCMinMode != config.CCMinNone {
+		learnts, btLevel = s.minimize(learnts, seen)
+	}
+	s.varBumpActivity(analyzed, learnts)
+
 	return learnts, btLevel
 }
 
-// record records a new learnt clause.
-func (s *Solver) record(lits []lit.Lit) {
+// analyzeFinal records the assumptions the true literals ps were implied by as
+// the failed assumptions.
+func (s *Solver) analyzeFinal(ps []lit.Lit) {
+	seen := make([]bool, s.NVars())
+
+	for _, q := range ps {
+		seen[q.Index()] = s.level[q.Index()] > 0
+	}
+	for i := s.NAssigns() - 1; i >= 0; i-- {
+		p := s.trail[i]
+
+		if !seen[p.Index()] {
+			continue
+		}
+		var pReason []lit.Lit
+
+		switch r := s.reasonOf(p.Index()); {
+		case r.decision():
+			s.failed = append(s.failed, s.userInt(p))
+			continue
+		case r.clause != crefUndef:
+			pReason = s.clause(r.clause).calcReason(p)
+		default:
+			pReason = []lit.Lit{r.other.Not()}
+		}
+		for _, q := range pReason {
+			seen[q.Index()] = seen[q.Index()] || s.level[q.Index()] > 0
+		}
+	}
+}
+
+// conflictLevel returns the highest decision level in the conflict clause,
+// which is lower than the current one when the conflict was caused by
+// literals assigned out of order.
+func (s *Solver) conflictLevel(c cref) int {
+	max := 0
+	for _, q := range s.ca.lits(c) {
+		if level := s.level[q.Index()]; level > max {
+			max = level
+		}
+	}
+	return max
+}
+
+// minimize removes redundant literals from a learnt clause, returning the
+// shortened clause and its new backtrack level. A literal is redundant when it
+// is implied by the other literals in the clause.
+func (s *Solver) minimize(learnts []lit.Lit, seen []bool) ([]lit.Lit, int) {
+	abstractLevels := uint32(0)
+	for i := 1; i < len(learnts); i++ {
+		abstractLevels |= s.abstractLevel(learnts[i].Index())
+	}
+	j := 1
+	btLevel := 0
+
+	for i := 1; i < len(learnts); i++ {
+		p := learnts[i]
+
+		if !s.reason[p.Index()].decision() {
+			switch s.config.CCMinMode {
+			case config.CCMinLocal:
+				if s.litRedundantLocal(p, seen) {
+					continue
+				}
+			case config.CCMinRecursive:
+				if s.litRedundant(p, abstractLevels, seen) {
+					continue
+				}
+			}
+		}
+		learnts[j] = p
+		j++
+
+		// Keep track of highest level to return.
+		if level := s.level[p.Index()]; level > btLevel {
+			btLevel = level
+		}
+	}
+	return learnts[:j], btLevel
+}
+
+// litRedundantLocal returns true if every literal in p's reason is either in
+// the learnt clause or assigned at the top level.
+func (s *Solver) litRedundantLocal(p lit.Lit, seen []bool) bool {
+	for _, q := range s.reasonLits(s.reasonOf(p.Index())) {
+		if !seen[q.Index()] && s.level[q.Index()] > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// litRedundant returns true if p is implied by the literals in the learnt
+// clause, found by recursively walking p's implication graph. Literals proven
+// redundant are marked as seen so later checks can stop at them. Walks that
+// reach a decision, or a level not present in the clause, fail early.
+func (s *Solver) litRedundant(p lit.Lit, abstractLevels uint32, seen []bool) bool {
+	stack := []lit.Lit{p}
+	toClear := []int{}
+
+	for len(stack) > 0 {
+		r := s.reasonOf(stack[len(stack)-1].Index())
+		stack = stack[:len(stack)-1]
+
+		for _, q := range s.reasonLits(r) {
+			v := q.Index()
+
+			if seen[v] || s.level[v] == 0 {
+				continue
+			}
+			if s.reason[v].decision() || s.abstractLevel(v)&abstractLevels == 0 {
+				// Undo marks made by this walk, since they were not proven.
+				for _, u := range toClear {
+					seen[u] = false
+				}
+				return false
+			}
+			seen[v] = true
+			stack = append(stack, q)
+			toClear = append(toClear, v)
+		}
+	}
+	return true
+}
+
+// abstractLevel returns a bitmask abstraction of the variable's decision
+// level, used to quickly rule out literals during minimization.
+func (s *Solver) abstractLevel(v int) uint32 {
+	return 1 << (uint(s.level[v]) & 31)
+}
+
+// record records a new learnt clause, returning its literal block distance.
+func (s *Solver) record(lits []lit.Lit) int {
+	// The asserting literal is unassigned after backtracking, but was alone on
+	// the conflict level when the clause was learnt.
+	lbd := s.computeLBD(lits[1:]) + 1
+
 	_, c := newClause(s, lits, true)
-	s.enqueue(lits[0], c)
 
-	if c != nil {
+	if len(lits) == 2 {
+		s.enqueue(lits[0], binaryReason(lits[1]))
+	} else {
+		s.enqueue(lits[0], clauseReason(c))
+	}
+
+	if c != crefUndef {
+		s.ca.setField(c, hdrLBD, lbd)
+		s.ca.setField(c, hdrTouched, s.conflicts)
+		s.ca.setTier(c, tierFor(lbd))
 		s.learnts = append(s.learnts, c)
 	}
+	if s.exchange != nil && (len(lits) <= shareMaxLen || lbd <= shareMaxLBD) {
+		s.exportShared(lits, lbd)
+	}
+	return lbd
+}
+
+// computeLBD returns the literal block distance of lits, i.e. the number of
+// distinct decision levels among them.
+func (s *Solver) computeLBD(lits []lit.Lit) int {
+	s.lbdStamp++
+	lbd := 0
+
+	for _, p := range lits {
+		level := s.level[p.Index()]
+
+		if level >= 0 && s.lbdStamps[level] != s.lbdStamp {
+			s.lbdStamps[level] = s.lbdStamp
+			lbd++
+		}
+	}
+	return lbd
 }
@@ -0,0 +1,72 @@
+package solver
+
+import (
+	"github.com/ericr/saturday/config"
+	"github.com/ericr/saturday/lit"
+	"testing"
+)
+
+func TestMinimizeRecursive(t *testing.T) {
+	conf := config.New()
+	s := New(conf)
+
+	// 1 implies 2 implies 3, all at decision level 1.
+	s.AddClause([]int{-1, 2})
+	s.AddClause([]int{-2, 3})
+	s.AddClause([]int{4, 5})
+	s.assume(lit.NewFromInt(1))
+	s.propagate()
+	s.assume(lit.NewFromInt(-4))
+	s.propagate()
+
+	// ~3 is implied by ~1 through 2, so it can be dropped.
+	learnts := []lit.Lit{lit.NewFromInt(-5), lit.NewFromInt(-1), lit.NewFromInt(-3)}
+	seen := make([]bool, s.NVars())
+	for _, p := range learnts {
+		seen[p.Index()] = true
+	}
+	if min, btLevel := s.minimize(learnts, seen); len(min) != 2 || btLevel != 1 {
+		t.Fatalf("Did not minimize clause, got: %v (level %d)", min, btLevel)
+	}
+}
+
+func TestMinimizeLocal(t *testing.T) {
+	conf := config.New()
+	conf.CCMinMode = config.CCMinLocal
+	s := New(conf)
+
+	s.AddClause([]int{-1, 2})
+	s.AddClause([]int{-2, 3})
+	s.AddClause([]int{4, 5})
+	s.assume(lit.NewFromInt(1))
+	s.propagate()
+	s.assume(lit.NewFromInt(-4))
+	s.propagate()
+
+	// ~3's reason contains ~2, which is not in the clause.
+	learnts := []lit.Lit{lit.NewFromInt(-5), lit.NewFromInt(-1), lit.NewFromInt(-3)}
+	seen := make([]bool, s.NVars())
+	for _, p := range learnts {
+		seen[p.Index()] = true
+	}
+	if min, _ := s.minimize(learnts, seen); len(min) != 3 {
+		t.Fatalf("Minimized clause beyond immediate reasons, got: %v", min)
+	}
+}
+
+func TestComputeLBD(t *testing.T) {
+	conf := config.New()
+	s := New(conf)
+
+	s.AddClause([]int{-1, 2})
+	s.AddClause([]int{3, 4})
+	s.assume(lit.NewFromInt(1))
+	s.propagate()
+	s.assume(lit.NewFromInt(-3))
+	s.propagate()
+
+	lits := []lit.Lit{lit.NewFromInt(-1), lit.NewFromInt(-2), lit.NewFromInt(-4)}
+	if lbd := s.computeLBD(lits); lbd != 2 {
+		t.Fatalf("TestComputeLBD() failed, got: %d", lbd)
+	}
+}
@@ -0,0 +1,164 @@
+package solver
+
+import (
+	"github.com/ericr/saturday/config"
+	"io"
+	"log"
+	"math/rand"
+	"runtime"
+	"testing"
+)
+
+// random3SAT returns a uniform random 3-SAT instance with n variables at the
+// given clause to variable ratio.
+func random3SAT(seed int64, n int, ratio float64) [][]int {
+	r := rand.New(rand.NewSource(seed))
+	clauses := [][]int{}
+
+	for i := 0; i < int(float64(n)*ratio); i++ {
+		clause := []int{}
+
+		for len(clause) < 3 {
+			v := r.Intn(n) + 1
+
+			if containsVar(clause, v) {
+				continue
+			}
+			if r.Intn(2) == 0 {
+				v = -v
+			}
+			clause = append(clause, v)
+		}
+		clauses = append(clauses, clause)
+	}
+	return clauses
+}
+
+// containsVar returns true if v or -v is in the clause.
+func containsVar(clause []int, v int) bool {
+	for _, p := range clause {
+		if p == v || p == -v {
+			return true
+		}
+	}
+	return false
+}
+
+// pigeonhole returns the unsatisfiable instance of placing n+1 pigeons into n
+// holes.
+func pigeonhole(n int) [][]int {
+	clauses := [][]int{}
+	v := func(p, h int) int { return p*n + h + 1 }
+
+	for p := 0; p <= n; p++ {
+		clause := []int{}
+		for h := 0; h < n; h++ {
+			clause = append(clause, v(p, h))
+		}
+		clauses = append(clauses, clause)
+	}
+	for h := 0; h < n; h++ {
+		for p := 0; p <= n; p++ {
+			for q := p + 1; q <= n; q++ {
+				clauses = append(clauses, []int{-v(p, h), -v(q, h)})
+			}
+		}
+	}
+	return clauses
+}
+
+func benchmarkSolve(b *testing.B, clauses [][]int) {
+	conf := config.New()
+	conf.Logger = log.New(io.Discard, "", 0)
+	propagations := 0
+
+	for i := 0; i < b.N; i++ {
+		s := New(conf)
+		for _, clause := range clauses {
+			s.AddClause(append([]int{}, clause...))
+		}
+		s.Solve([]int{})
+		propagations += s.NPropagations()
+	}
+	b.ReportMetric(float64(propagations)/b.Elapsed().Seconds(), "props/s")
+}
+
+// branchingConflicts returns the number of conflicts needed to solve random
+// 3-SAT instances with n variables at the threshold, for the given seeds, by
+// branching with a focused and optionally a stable mode heuristic.
+func branchingConflicts(focused string, stable string, n int, seeds int) int {
+	conf := config.New()
+	conf.Logger = log.New(io.Discard, "", 0)
+	conf.Branching = focused
+	conf.StableBranching = stable
+	conflicts := 0
+
+	for seed := 1; seed <= seeds; seed++ {
+		s := New(conf)
+		for _, clause := range random3SAT(int64(seed), n, 4.26) {
+			s.AddClause(clause)
+		}
+		s.Solve([]int{})
+		conflicts += s.NConflicts()
+	}
+	return conflicts
+}
+
+func BenchmarkBranching(b *testing.B) {
+	for _, h := range [][2]string{
+		{config.BranchingVSIDS, ""},
+		{config.BranchingVMTF, ""},
+		{config.BranchingLRB, ""},
+		{config.BranchingVMTF, config.BranchingVSIDS},
+		{config.BranchingVSIDS, config.BranchingLRB},
+	} {
+		name := h[0]
+		if h[1] != "" {
+			name += "/" + h[1]
+		}
+		b.Run(name, func(b *testing.B) {
+			conflicts := 0
+
+			for i := 0; i < b.N; i++ {
+				conflicts += branchingConflicts(h[0], h[1], 200, 5)
+			}
+			b.ReportMetric(float64(conflicts)/float64(b.N), "conflicts/op")
+		})
+	}
+}
+
+func BenchmarkSolveRandom3SAT(b *testing.B) {
+	benchmarkSolve(b, random3SAT(1, 150, 4.26))
+}
+
+func BenchmarkSolveRandom3SATLarge(b *testing.B) {
+	benchmarkSolve(b, random3SAT(2, 500, 3.6))
+}
+
+func BenchmarkSolvePigeonhole(b *testing.B) {
+	benchmarkSolve(b, pigeonhole(7))
+}
+
+func BenchmarkLoadMemory(b *testing.B) {
+	conf := config.New()
+	conf.Logger = log.New(io.Discard, "", 0)
+	clauses := random3SAT(3, 100000, 4.2)
+	ms := runtime.MemStats{}
+	heap := uint64(0)
+
+	for i := 0; i < b.N; i++ {
+		runtime.GC()
+		runtime.ReadMemStats(&ms)
+		before := ms.HeapAlloc
+
+		s := New(conf)
+		for _, clause := range clauses {
+			s.AddClause(append([]int{}, clause...))
+		}
+		runtime.GC()
+		runtime.ReadMemStats(&ms)
+		heap += ms.HeapAlloc - before
+		runtime.KeepAlive(s)
+	}
+	b.ReportMetric(float64(heap)/float64(b.N)/float64(len(clauses)), "B/clause")
+}
@@ -1,42 +1,166 @@
 package solver
 
+import "sort"
+
+const (
+	// coreLBD is the highest literal block distance of core tier clauses.
+	coreLBD = 2
+	// tier2LBD is the highest literal block distance of tier 2 clauses.
+	tier2LBD = 6
+	// tierCheckInterval is the number of conflicts between tier 2 checks.
+	tierCheckInterval = 10000
+	// tier2MaxIdle is the number of conflicts a tier 2 clause may go unused
+	// before it's demoted to the local tier.
+	tier2MaxIdle = 30000
+)
+
 // simplifyDB can be called before solve() and simplifies the constraint
 // database. If a top-level conflict is found, returns false.
 func (s *Solver) simplifyDB() bool {
-	if s.propagate() != nil {
+	if s.propagate() != crefUndef {
 		return false
 	}
 	j := 0
 	for i := 0; i < s.NLearnts(); i++ {
-		if s.learnts[i].simplify() {
-			s.learnts[i].remove()
+		if c := s.clause(s.learnts[i]); c.simplify() {
+			c.remove()
 		} else {
 			s.learnts[j] = s.learnts[i]
 			j++
 		}
 	}
 	s.learnts = s.learnts[:j]
+	s.checkGarbage()
 
 	return true
 }
 
-// reduceDB removes half of the learnt clauses minus some locked clauses.
+// reduceDB removes half of the local tier learnt clauses, preferring to
+// remove clauses with a high literal block distance and low activity. Locked
+// clauses and clauses used since the last call are kept.
 func (s *Solver) reduceDB() {
-	i := 0
-	j := 0
-	lim := s.claInc / float64(s.NLearnts())
-
-	s.sortLearnts()
+	local := []cref{}
 
-	for i, j = 0, 0; i < s.NLearnts(); i++ {
-		c := s.learnts[i]
+	for _, c := range s.learnts {
+		if s.ca.tier(c) == tierLocal {
+			local = append(local, c)
+		}
+	}
+	sort.Slice(local, func(i, j int) bool {
+		lbdI, lbdJ := s.ca.field(local[i], hdrLBD), s.ca.field(local[j], hdrLBD)
+		if lbdI != lbdJ {
+			return lbdI > lbdJ
+		}
+		return s.ca.activity(local[i]) < s.ca.activity(local[j])
+	})
+	for i := 0; i < len(local)/2; i++ {
+		c := s.clause(local[i])
 
-		if c.Len() > 2 && !c.locked() && (i < s.NLearnts()/2 || c.activity < lim) {
+		if s.ca.flag(c.ref, flagUsed) {
+			s.ca.setFlag(c.ref, flagUsed, false)
+		} else if !c.locked() {
 			c.remove()
-		} else {
+		}
+	}
+	j := 0
+	for i := 0; i < s.NLearnts(); i++ {
+		if !s.ca.flag(s.learnts[i], flagDeleted) {
 			s.learnts[j] = s.learnts[i]
 			j++
 		}
 	}
+	if s.tracer != nil {
+		s.tracer.ReduceDB(s.NLearnts()-j, j)
+	}
 	s.learnts = s.learnts[:j]
+	s.reduceDBs++
+	s.nextReduceDB = s.conflicts + s.reduceDBFirst + s.reduceDBInc*s.reduceDBs
+	s.checkGarbage()
+}
+
+// checkGarbage compacts the clause arena once a fifth of it is wasted.
+func (s *Solver) checkGarbage() {
+	if s.ca.wasted*5 > len(s.ca.mem) {
+		s.garbageCollect()
+	}
+}
+
+// garbageCollect moves all live clauses into a new arena, updating every
+// reference to them.
+func (s *Solver) garbageCollect() {
+	to := newClauseArena(len(s.ca.mem) - s.ca.wasted)
+
+	// Reasons are relocated first, so clauses keep their trail order. Reasons
+	// of deleted clauses are only left at the top level.
+	for _, p := range s.trail {
+		r := &s.reason[p.Index()]
+
+		if r.clause != crefUndef && !r.external() {
+			if s.ca.flag(r.clause, flagDeleted) {
+				*r = noReason
+			} else {
+				r.clause = s.ca.relocate(r.clause, to)
+			}
+		}
+	}
+	for p, ws := range s.watches {
+		j := 0
+		for _, w := range ws {
+			if !s.ca.flag(w.clause, flagDeleted) {
+				w.clause = s.ca.relocate(w.clause, to)
+				ws[j] = w
+				j++
+			}
+		}
+		s.watches[p] = ws[:j]
+	}
+	for i, c := range s.constrs {
+		s.constrs[i] = s.ca.relocate(c, to)
+	}
+	for i, c := range s.learnts {
+		s.learnts[i] = s.ca.relocate(c, to)
+	}
+	s.ca = to
+}
+
+// demoteTier2 moves tier 2 clauses that haven't been used recently into the
+// local tier.
+func (s *Solver) demoteTier2() {
+	for _, c := range s.learnts {
+		if s.ca.tier(c) == tierTwo && s.conflicts-s.ca.field(c, hdrTouched) > tier2MaxIdle {
+			s.ca.setTier(c, tierLocal)
+		}
+	}
+	s.nextTierCheck = s.conflicts + tierCheckInterval
+}
+
+// tierFor returns the tier for a learnt clause with the given literal block
+// distance.
+func tierFor(lbd int) int {
+	switch {
+	case lbd <= coreLBD:
+		return tierCore
+	case lbd <= tier2LBD:
+		return tierTwo
+	default:
+		return tierLocal
+	}
+}
+
+// bumpLBD recomputes a learnt clause's literal block distance after it takes
+// part in a conflict, promoting it to a better tier if it improved.
+func (s *Solver) bumpLBD(c cref) {
+	s.ca.setField(c, hdrTouched, s.conflicts)
+	s.ca.setFlag(c, flagUsed, true)
+
+	if s.ca.tier(c) == tierCore {
+		return
+	}
+	if lbd := s.computeLBD(s.ca.lits(c)); lbd < s.ca.field(c, hdrLBD) {
+		s.ca.setField(c, hdrLBD, lbd)
+
+		if t := tierFor(lbd); t < s.ca.tier(c) {
+			s.ca.setTier(c, t)
+		}
+	}
 }
@@ -1,38 +1,85 @@
 package solver
 
 import (
+	"github.com/ericr/saturday/config"
 	"github.com/ericr/saturday/lit"
-	"sort"
+	"github.com/ericr/saturday/order"
 )
 
-// varBumpActivity bumps a variable's activity.
-func (s *Solver) varBumpActivity(p lit.Lit) {
-	s.activity[p.Index()] += s.varInc
+// modeSwitchInterval is the number of conflicts before the first mode switch,
+// which doubles with every switch.
+const modeSwitchInterval = 1000
 
-	if s.activity[p.Index()] > 1e100 {
-		s.varRescaleActivity()
+// newOrder returns the variable order named by the given branching heuristic,
+// falling back to VSIDS if the name is unknown.
+func (s *Solver) newOrder(name string) order.Order {
+	switch name {
+	case config.BranchingVSIDS:
+		return order.NewVSIDS(&s.assigns, s.config.VarDecay, s.rand)
+	case config.BranchingVMTF:
+		return order.NewVMTF(&s.assigns)
+	case config.BranchingLRB:
+		return order.NewLRB(&s.assigns, s.rand)
 	}
-	s.order.Fix(p.Index())
+	s.logger.Printf("Unknown branching heuristic %q, using %q", name,
+		config.BranchingVSIDS)
+
+	return order.NewVSIDS(&s.assigns, s.config.VarDecay, s.rand)
 }
 
-// varDecayActivity applies decay to varInc.
-func (s *Solver) varDecayActivity() {
-	s.varInc *= s.varDecay
+// pickBranchVar returns the variable to decide on next, which is a random one
+// with the configured frequency.
+func (s *Solver) pickBranchVar() int {
+	if s.config.RandomFreq > 0 && s.rand.Float64() < s.config.RandomFreq {
+		if v := s.rand.Intn(s.NVars()); s.assigns[v].Undef() {
+			return v
+		}
+	}
+	return s.order.Choose() - 1
 }
 
-// varRescaleActivity rescales var activity.
-func (s *Solver) varRescaleActivity() {
-	for i := 0; i < s.NVars(); i++ {
-		s.activity[i] *= 1e-100
+// switchMode alternates between focused and stable mode, switching to the
+// mode's variable order. Both orders follow the search all along, so the
+// switched to order continues from where it was left.
+func (s *Solver) switchMode() {
+	s.stable = !s.stable
+	s.modeSwitches++
+	s.nextModeSwitch = s.conflicts + modeSwitchInterval<<uint(s.modeSwitches)
+
+	if s.stable {
+		s.order = s.orders[1]
+	} else {
+		s.order = s.orders[0]
+	}
+}
+
+// varBumpActivity bumps the activity of the variables analyzed in a conflict
+// and of those in the learnt clause. Every order is bumped, so that the
+// inactive one is up to date when switching modes.
+func (s *Solver) varBumpActivity(analyzed []int, learnts []lit.Lit) {
+	learnt := make([]int, len(learnts))
+
+	for i, p := range learnts {
+		learnt[i] = p.Index()
+	}
+	for _, o := range s.orders {
+		o.Bump(analyzed, learnt)
+	}
+}
+
+// varDecayActivity applies decay to variable activity.
+func (s *Solver) varDecayActivity() {
+	for _, o := range s.orders {
+		o.Decay()
 	}
-	s.varInc *= 1e-100
 }
 
 // claBumpActivity bumps a clause's activity.
-func (s *Solver) claBumpActivity(c *Clause) {
-	c.activity += s.claInc
+func (s *Solver) claBumpActivity(c cref) {
+	activity := s.ca.activity(c) + s.claInc
+	s.ca.setActivity(c, activity)
 
-	if c.activity+s.claInc > 1e20 {
+	if activity+s.claInc > 1e20 {
 		s.claRescaleActivity()
 	}
 }
@@ -45,7 +92,8 @@ func (s *Solver) claDecayActivity() {
 // claRescaleActivity rescales clause activity.
 func (s *Solver) claRescaleActivity() {
 	for i := 0; i < s.NLearnts(); i++ {
-		s.learnts[i].activity *= 1e-20
+		c := s.learnts[i]
+		s.ca.setActivity(c, s.ca.activity(c)*1e-20)
 	}
 	s.claInc *= 1e-20
 }
@@ -55,10 +103,3 @@ func (s *Solver) decayActivities() {
 	s.varDecayActivity()
 	s.claDecayActivity()
 }
-
-// sortLearnts sorts learnts by activity.
-func (s *Solver) sortLearnts() {
-	sort.Slice(s.learnts, func(i, j int) bool {
-		return s.learnts[i].activity < s.learnts[i].activity
-	})
-}
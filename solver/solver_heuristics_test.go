@@ -0,0 +1,24 @@
+package solver
+
+import (
+	"github.com/ericr/saturday/config"
+	"testing"
+)
+
+func TestBranchingConflicts(t *testing.T) {
+	vsids := branchingConflicts(config.BranchingVSIDS, "", 150, 10)
+
+	// The other heuristics need more conflicts on random instances, but not
+	// many times as many.
+	for _, h := range [][2]string{
+		{config.BranchingVMTF, ""},
+		{config.BranchingLRB, ""},
+		{config.BranchingVMTF, config.BranchingVSIDS},
+		{config.BranchingVSIDS, config.BranchingLRB},
+	} {
+		if got := branchingConflicts(h[0], h[1], 150, 10); got > 4*vsids {
+			t.Fatalf("TestBranchingConflicts() failed on %v, got: %d conflicts, VSIDS: %d",
+				h, got, vsids)
+		}
+	}
+}
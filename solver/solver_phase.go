@@ -0,0 +1,136 @@
+package solver
+
+import (
+	"github.com/ericr/saturday/config"
+	"github.com/ericr/saturday/sls"
+	"github.com/ericr/saturday/tribool"
+)
+
+const (
+	// rephaseInterval is the number of conflicts between rephases, which grows
+	// arithmetically with every rephase.
+	rephaseInterval = 1000
+	// walkEffort is the number of local search flips per thousand
+	// propagations since the last walk.
+	walkEffort = 50
+)
+
+// pickPhase returns the value to assign to v when deciding on it. User-set
+// polarities take precedence over target phases, which take precedence over
+// saved phases.
+func (s *Solver) pickPhase(v int) bool {
+	switch {
+	case !s.userPhases[v].Undef():
+		return s.userPhases[v].True()
+	case s.config.Rephase && !s.targetPhases[v].Undef():
+		return s.targetPhases[v].True()
+	case s.config.PhaseSaving:
+		return s.phases[v]
+	}
+	return s.defaultPhase()
+}
+
+// defaultPhase returns the configured default polarity for a decision.
+func (s *Solver) defaultPhase() bool {
+	switch s.config.Polarity {
+	case config.PolarityFalse:
+		return false
+	case config.PolarityRandom:
+		return s.rand.Intn(2) == 0
+	}
+	return true
+}
+
+// saveTrailPhases updates the target and best phases when the conflict-free
+// part of the trail, i.e. everything below the conflict's decision level, is
+// larger than the one they were last taken from.
+func (s *Solver) saveTrailPhases() {
+	if !s.config.Rephase {
+		return
+	}
+	n := s.trailLim[s.decisionLevel()-1]
+
+	if n > s.targetLen {
+		s.copyTrailPhases(s.targetPhases, n)
+		s.targetLen = n
+	}
+	if n > s.bestLen {
+		s.copyTrailPhases(s.bestPhases, n)
+		s.bestLen = n
+	}
+}
+
+// copyTrailPhases stores the values of the first n trail literals in phases.
+func (s *Solver) copyTrailPhases(phases []tribool.Tribool, n int) {
+	for i := range phases {
+		phases[i] = tribool.Undef
+	}
+	for _, p := range s.trail[:n] {
+		phases[p.Index()] = tribool.NewFromBool(!p.Sign())
+	}
+}
+
+// rephase resets the saved phases, cycling through the original, best and
+// inverted phases, and clears the target phases. Best phases are improved by
+// local search when enabled.
+func (s *Solver) rephase() {
+	s.rephases++
+
+	switch s.rephases % 4 {
+	case 0:
+		// Original phases.
+		for v := range s.phases {
+			s.phases[v] = s.defaultPhase()
+		}
+	case 1, 3:
+		// Best phases.
+		for v, b := range s.bestPhases {
+			if !b.Undef() {
+				s.phases[v] = b.True()
+			}
+		}
+		s.bestLen = 0
+
+		if s.config.LocalSearchRephase {
+			s.walk()
+		}
+	case 2:
+		// Inverted phases.
+		for v := range s.phases {
+			s.phases[v] = !s.defaultPhase()
+		}
+	}
+	for v := range s.targetPhases {
+		s.targetPhases[v] = tribool.Undef
+	}
+	s.targetLen = 0
+	s.nextRephase = s.conflicts + rephaseInterval*(s.rephases+1)
+}
+
+// walk improves the saved phases by local search, starting from them. Its
+// flips are bounded by the propagations since the last walk, and it waits for
+// enough of them to be worth building the searcher.
+func (s *Solver) walk() {
+	flips := (s.propagations - s.walkPropagations) * walkEffort / 1000
+
+	if flips < s.NConstrs() {
+		return
+	}
+	s.walkPropagations = s.propagations
+	clauses := make([][]int, 0, s.NConstrs())
+
+	for _, c := range s.constrs {
+		clauses = append(clauses, s.clause(c).asInts())
+	}
+	// Top-level assignments are fixed.
+	for _, p := range s.trail {
+		if s.level[p.Index()] == 0 {
+			clauses = append(clauses, []int{p.Int()})
+		}
+	}
+	w := sls.New(clauses, s.config)
+	w.SetSeed(s.rand.Int63())
+	w.SetPhases(s.phases)
+	w.Solve(flips)
+	copy(s.phases, w.Best())
+}
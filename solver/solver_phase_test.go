@@ -0,0 +1,39 @@
+package solver
+
+import (
+	"github.com/ericr/saturday/config"
+	"testing"
+)
+
+func TestSetPolarity(t *testing.T) {
+	conf := config.New()
+	s := New(conf)
+
+	s.AddClause([]int{1, 2})
+	s.SetPolarity(1, false)
+	s.SetPolarity(2, true)
+
+	if !s.Solve([]int{}) {
+		t.Fatalf("TestSetPolarity() failed: unsat")
+	}
+	if a := s.Answer(); a[0] != -1 || a[1] != 2 {
+		t.Fatalf("TestSetPolarity() failed, got: %v", a)
+	}
+}
+
+func TestPhaseSaving(t *testing.T) {
+	conf := config.New()
+	conf.Polarity = config.PolarityFalse
+	s := New(conf)
+
+	s.AddClause([]int{1, 2})
+	s.AddClause([]int{-1, 3})
+	s.Solve([]int{})
+
+	if s.phases[0] {
+		t.Fatalf("TestPhaseSaving() failed: var 1 saved as true")
+	}
+	if !s.phases[1] {
+		t.Fatalf("TestPhaseSaving() failed: var 2 saved as false")
+	}
+}
@@ -6,7 +6,7 @@ import (
 )
 
 // enqueue puts a new fact, p, into the propagation queue.
-func (s *Solver) enqueue(p lit.Lit, from *Clause) bool {
+func (s *Solver) enqueue(p lit.Lit, from antecedent) bool {
 	// Check if the fact isn't new first.
 	if s.litValue(p) != tribool.Undef {
 		if s.litValue(p).False() {
@@ -21,32 +21,83 @@ func (s *Solver) enqueue(p lit.Lit, from *Clause) bool {
 	s.assigns[p.Index()] = tribool.NewFromBool(!p.Sign())
 	s.level[p.Index()] = s.decisionLevel()
 	s.reason[p.Index()] = from
+
+	// Implied literals may belong to a lower level after chronological
+	// backtracking.
+	if s.config.Chrono > 0 && !from.decision() && !from.external() {
+		s.level[p.Index()] = s.reasonLevel(from)
+	}
 	s.trail = append(s.trail, p)
-	s.propQ.Insert(p)
+	for _, o := range s.orders {
+		o.Assign(p.Index())
+	}
 
+	if s.tracer != nil && !from.decision() {
+		s.tracer.Propagation(s.userInt(p), s.level[p.Index()])
+	}
 	return true
 }
 
+// reasonLevel returns the highest decision level among an antecedent's false
+// literals.
+func (s *Solver) reasonLevel(from antecedent) int {
+	if from.clause == crefUndef {
+		return s.level[from.other.Index()]
+	}
+	max := 0
+	for _, q := range s.ca.lits(from.clause)[1:] {
+		if level := s.level[q.Index()]; level > max {
+			max = level
+		}
+	}
+	return max
+}
+
 // propagate propagates all enqueued facts.
-func (s *Solver) propagate() *Clause {
-	for s.propQ.Size() > 0 {
-		p := s.propQ.Dequeue()
-		tmp := s.watches[p]
-		
-		s.watches[p] = []*Clause{}
+func (s *Solver) propagate() cref {
+	for s.qhead < s.NAssigns() {
+		p := s.trail[s.qhead]
+		ws := s.watches[p]
+		j := 0
+
+		s.qhead++
 		s.propagations++
 
-		for i := 0; i < len(tmp); i++ {
-			// Check for conflict.
-			if !(tmp[i].propagate(p)) {
-				for j := i + 1; j < len(tmp); j++ {
-					s.watches[p] = append(s.watches[p], tmp[j])
+		// Watchers stay in p's watch list unless their clause moves to another
+		// literal, so the list can be rebuilt in place.
+		for i := 0; i < len(ws); i++ {
+			w := ws[i]
+
+			// Skip clauses already satisfied by their blocker.
+			if s.litValue(w.blocker).True() {
+				ws[j] = w
+				j++
+				continue
+			}
+			ok := true
+
+			if w.binary {
+				ws[j] = w
+				j++
+				ok = s.enqueue(w.blocker, binaryReason(p.Not()))
+			} else {
+				moved := false
+
+				if moved, ok = s.clause(w.clause).propagate(p); !moved {
+					ws[j] = watcher{clause: w.clause, blocker: s.ca.lits(w.clause)[0]}
+					j++
 				}
-				s.propQ.Clear()
+			}
+			// Check for conflict.
+			if !ok {
+				j += copy(ws[j:], ws[i+1:])
+				s.watches[p] = ws[:j]
+				s.qhead = s.NAssigns()
 
-				return tmp[i]
+				return w.clause
 			}
 		}
+		s.watches[p] = ws[:j]
 	}
-	return nil
+	return crefUndef
 }
@@ -0,0 +1,46 @@
+package solver
+
+import (
+	"github.com/ericr/saturday/config"
+	"github.com/ericr/saturday/lit"
+	"testing"
+)
+
+func TestPropagateBinary(t *testing.T) {
+	conf := config.New()
+	s := New(conf)
+
+	s.AddClause([]int{1, 2})
+	s.assume(lit.NewFromInt(-1))
+
+	if confl := s.propagate(); confl != crefUndef {
+		t.Fatalf("TestPropagateBinary() failed: conflict %s", s.clause(confl))
+	}
+	if !s.litValue(lit.NewFromInt(2)).True() {
+		t.Fatalf("TestPropagateBinary() failed: 2 not implied")
+	}
+	if r := s.reason[lit.NewFromInt(2).Index()]; r.clause != crefUndef || r.other != lit.NewFromInt(1) {
+		t.Fatalf("TestPropagateBinary() failed, got reason: %v", r)
+	}
+}
+
+func TestPropagateBlocker(t *testing.T) {
+	conf := config.New()
+	s := New(conf)
+
+	s.AddClause([]int{1, 2, 3})
+	s.assume(lit.NewFromInt(2))
+	s.propagate()
+	s.assume(lit.NewFromInt(-1))
+
+	if confl := s.propagate(); confl != crefUndef {
+		t.Fatalf("TestPropagateBlocker() failed: conflict %s", s.clause(confl))
+	}
+	// The clause is satisfied by its blocker, so it must not have moved.
+	if ws := s.watches[lit.NewFromInt(-1)]; len(ws) != 1 {
+		t.Fatalf("TestPropagateBlocker() failed, got watchers: %v", ws)
+	}
+	if !s.litValue(lit.NewFromInt(3)).Undef() {
+		t.Fatalf("TestPropagateBlocker() failed: 3 assigned")
+	}
+}
@@ -7,7 +7,6 @@ import (
 
 // searchParams are params supported by search.
 type searchParams struct {
-	varDecay float64
 	claDecay float64
 }
 
@@ -16,113 +15,186 @@ type searchParams struct {
 // continue.
 func (s *Solver) search(params searchParams) tribool.Tribool {
 	// Update decay vars from search params.
-	s.varDecay = 1 / params.varDecay
 	s.claDecay = 1 / params.claDecay
 
-	// Reset model and number of conflicts.
+	// Reset model.
 	s.model = map[int]bool{}
-	nConflicts := 0
+
+	// rejected is whether the external propagator rejected the assignment
+	// since the last conflict or decision.
+	rejected := false
 
 	for {
-		if confl := s.propagate(); confl != nil {
+		if confl := s.propagateAll(); confl != crefUndef {
 			// Conflict detected.
-			nConflicts++
 			s.conflicts++
+			rejected = false
 
 			// No more decisions can be made.
-			if s.decisionLevel() == s.rootLevel {
+			conflLevel := s.conflictLevel(confl)
+			if conflLevel <= s.rootLevel {
+				s.analyzeFinal(s.clause(confl).calcReason(lit.Undef))
 				return tribool.False
 			}
+			// Analyze the conflict at the level it occurred on.
+			s.cancelUntil(conflLevel)
+
+			// Remember the conflict-free part of the trail.
+			s.saveTrailPhases()
 
 			// Analyze the conflict and produce a learnt clause.
 			learntClause, backtrackLevel := s.analyze(confl)
 
-			// Perform backtracking.
-			if backtrackLevel > s.rootLevel {
-				s.cancelUntil(backtrackLevel)
-			} else {
-				s.cancelUntil(s.rootLevel)
+			// Perform backtracking, only a single level if the backjump is long.
+			if backtrackLevel < s.rootLevel {
+				backtrackLevel = s.rootLevel
+			}
+			if s.config.Chrono > 0 && s.decisionLevel()-backtrackLevel > s.config.Chrono {
+				backtrackLevel = s.decisionLevel() - 1
 			}
+			s.cancelUntil(backtrackLevel)
 
+			if s.tracer != nil {
+				s.tracer.Conflict(s.userInts(learntClause), backtrackLevel)
+			}
 			// Record new learnt clause.
-			s.record(learntClause)
+			lbd := s.record(learntClause)
+			s.restartPolicy.Conflict(lbd)
 
 			// Update heuristics.
 			s.decayActivities()
-			s.maxLearntsCtr -= 1
-
-			if s.maxLearntsCtr == 0 {
-				s.maxLearntsCtrInc *= s.maxLearntsCtrIncGrowth
-				s.maxLearntsCtr = int(s.maxLearntsCtrInc)
-				s.maxLearnts *= s.maxLearntsGrowth
-			}
 		} else {
 			// No conflict detected.
 
-			// Simplify problem clauses.
+			// Import clauses shared by concurrent solvers and simplify problem
+			// clauses.
 			if s.decisionLevel() == 0 {
+				if s.exchange != nil && !s.importShared() {
+					return tribool.False
+				}
 				s.simplifyDB()
 			}
 
-			// Check if maxLearnts has been reached, and if so reduce the DB.
-			if s.NLearnts()-s.NAssigns() >= int(s.maxLearnts) {
+			// Demote unused tier 2 clauses and reduce the local tier on schedule.
+			if s.conflicts >= s.nextTierCheck {
+				s.demoteTier2()
+			}
+			if s.conflicts >= s.nextReduceDB {
 				s.reduceDB()
 			}
+			if s.config.Rephase && s.conflicts >= s.nextRephase {
+				s.rephase()
+			}
 
 			if s.NAssigns() == s.NVars() {
+				// Let the external propagator refute the model by adding a clause.
+				if s.propagator != nil && !s.checkModel() {
+					// The clause refuting the model is propagated next. Without one
+					// the model would be rejected over and over, so give up.
+					if rejected {
+						s.logger.Print("External propagator rejected a model without a falsified clause")
+						s.Interrupt()
+						s.cancelUntil(s.rootLevel)
+
+						return tribool.Undef
+					}
+					rejected = true
+					continue
+				}
 				// All vars are assigned with no conflicts, so we know we have a model.
 				for i := 0; i < s.NVars(); i++ {
 					s.model[s.internalVars[i]] = s.assigns[i] == tribool.True
 				}
 				s.cancelUntil(s.rootLevel)
 
+				if s.tracer != nil {
+					s.tracer.Model(s.Answer())
+				}
 				return tribool.True
 			}
 
-			// Force a restart if max conflicts is reached
-			if nConflicts >= int(s.maxConflicts) {
+			// Force a restart if the restart policy says so, or stop when
+			// interrupted.
+			if s.restartPolicy.Restart() || s.interrupted.Load() {
 				s.cancelUntil(s.rootLevel)
 
+				if s.tracer != nil && !s.interrupted.Load() {
+					s.tracer.Restart()
+				}
+
 				return tribool.Undef
 			}
 			// Decide on a new variable.
-			s.assume(lit.NewFromInt(s.order.Choose()))
+			v := s.pickBranchVar()
+			p := lit.New(v, !s.pickPhase(v))
+			s.assume(p)
 			s.decisions++
+			rejected = false
+
+			if s.tracer != nil {
+				s.tracer.Decision(s.userInt(p), s.decisionLevel())
+			}
 		}
 	}
 }
 
 // assume assumes a literal, returning false if immediate conflict.
 func (s *Solver) assume(p lit.Lit) bool {
+	if s.propagator != nil {
+		s.notifyAssignments()
+		s.propagator.NotifyNewDecisionLevel()
+	}
 	s.trailLim = append(s.trailLim, s.NAssigns())
 
-	return s.enqueue(p, nil)
+	return s.enqueue(p, noReason)
 }
 
-// undoOne unbinds the last assigned variable.
-func (s *Solver) undoOne() {
-	p := s.trail[s.NAssigns()-1]
-
+// unassign unbinds an assigned variable.
+func (s *Solver) unassign(p lit.Lit) {
+	if s.config.PhaseSaving {
+		s.phases[p.Index()] = !p.Sign()
+	}
 	s.assigns[p.Index()] = tribool.Undef
-	s.reason[p.Index()] = nil
+	s.reason[p.Index()] = noReason
 	s.level[p.Index()] = -1
-	s.trail = s.trail[:s.NAssigns()-1]
-	s.order.Push(p.Index())
-}
-
-// cancel reverts all variable assignments since the last decision level.
-func (s *Solver) cancel() {
-	c := s.NAssigns() - s.trailLim[s.decisionLevel()-1]
-	for ; c > 0; c-- {
-		s.undoOne()
+	for _, o := range s.orders {
+		o.Push(p.Index())
 	}
-	s.trailLim = s.trailLim[:s.decisionLevel()-1]
 }
 
-// cancelUntil cancels all variable assignments since the referenced level.
+// cancelUntil cancels all variable assignments above the referenced level.
+// Assignments at or below it that were made out of order are kept on the
+// trail and propagated again.
 func (s *Solver) cancelUntil(level int) {
-	for s.decisionLevel() > level {
-		s.cancel()
+	if s.decisionLevel() <= level {
+		return
+	}
+	start := s.trailLim[level]
+
+	for i := s.NAssigns() - 1; i >= start; i-- {
+		if p := s.trail[i]; s.level[p.Index()] > level {
+			s.unassign(p)
+		}
+	}
+	j := start
+	for _, p := range s.trail[start:] {
+		if !s.litValue(p).Undef() {
+			s.trail[j] = p
+			j++
+		}
+	}
+	s.trail = s.trail[:j]
+	s.trailLim = s.trailLim[:level]
+
+	if s.qhead > start {
+		s.qhead = start
+	}
+	if s.propagator != nil {
+		// Kept assignments are notified again, at their proper level.
+		if s.notified > start {
+			s.notified = start
+		}
+		s.propagator.NotifyBacktrack(level)
 	}
 }
 
@@ -0,0 +1,141 @@
+package solver
+
+import (
+	"fmt"
+	"github.com/ericr/saturday/config"
+	"github.com/ericr/saturday/lit"
+	"io"
+	"log"
+	"sort"
+	"testing"
+)
+
+func TestCancelUntilChrono(t *testing.T) {
+	conf := config.New()
+	s := New(conf)
+
+	s.AddClause([]int{-1, 2})
+	s.AddClause([]int{3, 4})
+
+	// Propagating after both decisions implies 2 on level 1, out of order.
+	s.assume(lit.NewFromInt(1))
+	s.assume(lit.NewFromInt(3))
+
+	if confl := s.propagate(); confl != crefUndef {
+		t.Fatalf("TestCancelUntilChrono() failed: conflict %s", s.clause(confl))
+	}
+	if level := s.level[lit.NewFromInt(2).Index()]; level != 1 {
+		t.Fatalf("TestCancelUntilChrono() failed, got level: %d", level)
+	}
+	s.cancelUntil(1)
+
+	if len(s.trail) != 2 || s.trail[1] != lit.NewFromInt(2) {
+		t.Fatalf("TestCancelUntilChrono() failed, got trail: %v", s.trail)
+	}
+	if !s.litValue(lit.NewFromInt(3)).Undef() {
+		t.Fatalf("TestCancelUntilChrono() failed: 3 still assigned")
+	}
+	if s.qhead > 1 {
+		t.Fatalf("TestCancelUntilChrono() failed, got qhead: %d", s.qhead)
+	}
+}
+
+func TestSeedDeterminism(t *testing.T) {
+	conf := config.New()
+	conf.Logger = log.New(io.Discard, "", 0)
+	conf.Seed = 42
+	conf.Polarity = config.PolarityRandom
+	conf.RandomFreq = 0.05
+	conf.LocalSearchRephase = true
+	clauses := random3SAT(7, 100, 4.26)
+	runs := [2]*Solver{}
+
+	for i := range runs {
+		runs[i] = New(conf)
+
+		for _, c := range clauses {
+			runs[i].AddClause(c)
+		}
+		runs[i].Solve([]int{})
+	}
+	a, b := runs[0], runs[1]
+
+	if a.NConflicts() != b.NConflicts() || a.NDecisions() != b.NDecisions() ||
+		a.NPropagations() != b.NPropagations() {
+		t.Fatalf("TestSeedDeterminism() failed, got conflicts: %d and %d",
+			a.NConflicts(), b.NConflicts())
+	}
+	if fmt.Sprint(a.Answer()) != fmt.Sprint(b.Answer()) {
+		t.Fatalf("TestSeedDeterminism() failed, got models: %v and %v",
+			a.Answer(), b.Answer())
+	}
+}
+
+func TestSolveIncremental(t *testing.T) {
+	conf := config.New()
+	s := New(conf)
+
+	s.AddClause([]int{1, 2})
+	s.AddClause([]int{-1, 3})
+
+	if !s.Solve([]int{1, 4}) {
+		t.Fatalf("TestSolveIncremental() failed, got: unsat")
+	}
+	if got := fmt.Sprint(s.Answer()); got != "[1 -2 3 4]" && got != "[1 2 3 4]" {
+		t.Fatalf("TestSolveIncremental() failed, got: %v", got)
+	}
+	if s.Solve([]int{-2, -3}) {
+		t.Fatalf("TestSolveIncremental() failed, got: sat")
+	}
+	s.AddClause([]int{-3})
+
+	if !s.Solve([]int{}) {
+		t.Fatalf("TestSolveIncremental() failed, got: unsat")
+	}
+	s.AddClause([]int{1})
+
+	if s.Solve([]int{}) {
+		t.Fatalf("TestSolveIncremental() failed, got: sat")
+	}
+
+	// A conflict found by propagating a unit must still be found once the top
+	// level propagations are done.
+	s = New(conf)
+	s.AddClause([]int{-1, 2})
+	s.AddClause([]int{-1, -2})
+	s.AddClause([]int{1})
+
+	for _, assumps := range [][]int{{}, {2}, {-2}} {
+		if s.Solve(assumps) {
+			t.Fatalf("TestSolveIncremental() failed on %v, got: sat", assumps)
+		}
+	}
+}
+
+func TestFailedAssumptions(t *testing.T) {
+	conf := config.New()
+	s := New(conf)
+
+	s.AddClause([]int{-1, 2})
+	s.AddClause([]int{-2, -3})
+	s.AddClause([]int{-5})
+
+	for _, test := range []struct {
+		assumps []int
+		want    string
+	}{
+		{[]int{1, 4, 3}, "[1 3]"},
+		{[]int{4, 3, 1}, "[1 3]"},
+		{[]int{4, 5}, "[5]"},
+	} {
+		if s.Solve(test.assumps) {
+			t.Fatalf("TestFailedAssumptions() failed, got: sat")
+		}
+		failed := append([]int{}, s.FailedAssumptions()...)
+		sort.Ints(failed)
+
+		if got := fmt.Sprint(failed); got != test.want {
+			t.Fatalf("TestFailedAssumptions() failed, got: %v", got)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package solver
+
+import (
+	"fmt"
+	"github.com/ericr/saturday/config"
+	"testing"
+)
+
+func TestSparseVars(t *testing.T) {
+	conf := config.New()
+	s := New(conf)
+
+	s.AddClause([]int{1000000000, -1})
+	s.AddClause([]int{1})
+
+	if !s.Solve([]int{}) {
+		t.Fatalf("TestSparseVars() failed, got: unsat")
+	}
+	if got := fmt.Sprint(s.Answer()); got != "[1 1000000000]" {
+		t.Fatalf("TestSparseVars() failed, got: %v", got)
+	}
+}
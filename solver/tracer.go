@@ -0,0 +1,69 @@
+package solver
+
+import (
+	"fmt"
+	"io"
+)
+
+// Tracer is notified of search events. Literals are given as in DIMACS.
+type Tracer interface {
+	// Decision is called after deciding on p at the given level.
+	Decision(p int, level int)
+	// Propagation is called after p was implied at the given level.
+	Propagation(p int, level int)
+	// Conflict is called after a conflict was analyzed into a learnt clause,
+	// with the level backtracked to.
+	Conflict(learnt []int, level int)
+	// Restart is called when the search restarts.
+	Restart()
+	// ReduceDB is called after learnt clauses were removed, with the number
+	// removed and kept.
+	ReduceDB(removed int, kept int)
+	// Model is called when a model is found.
+	Model(model []int)
+}
+
+// SetTracer sets the tracer notified of search events, or removes it if nil.
+func (s *Solver) SetTracer(t Tracer) {
+	s.tracer = t
+}
+
+// TextTracer writes search events to a writer, one per line.
+type TextTracer struct {
+	w io.Writer
+}
+
+// NewTextTracer returns a tracer writing to w.
+func NewTextTracer(w io.Writer) *TextTracer {
+	return &TextTracer{w: w}
+}
+
+// Decision implements the Tracer interface.
+func (t *TextTracer) Decision(p int, level int) {
+	fmt.Fprintf(t.w, "decide %d @%d\n", p, level)
+}
+
+// Propagation implements the Tracer interface.
+func (t *TextTracer) Propagation(p int, level int) {
+	fmt.Fprintf(t.w, "propagate %d @%d\n", p, level)
+}
+
+// Conflict implements the Tracer interface.
+func (t *TextTracer) Conflict(learnt []int, level int) {
+	fmt.Fprintf(t.w, "conflict learnt %v backjump @%d\n", learnt, level)
+}
+
+// Restart implements the Tracer interface.
+func (t *TextTracer) Restart() {
+	fmt.Fprintln(t.w, "restart")
+}
+
+// ReduceDB implements the Tracer interface.
+func (t *TextTracer) ReduceDB(removed int, kept int) {
+	fmt.Fprintf(t.w, "reducedb removed %d kept %d\n", removed, kept)
+}
+
+// Model implements the Tracer interface.
+func (t *TextTracer) Model(model []int) {
+	fmt.Fprintf(t.w, "model %v\n", model)
+}
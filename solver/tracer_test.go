@@ -0,0 +1,65 @@
+package solver
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/ericr/saturday/config"
+	"io"
+	"log"
+	"testing"
+)
+
+// countingTracer counts search events.
+type countingTracer struct {
+	decisions, propagations, conflicts, restarts, reduceDBs int
+	models                                                  [][]int
+}
+
+func (t *countingTracer) Decision(p int, level int)        { t.decisions++ }
+func (t *countingTracer) Propagation(p int, level int)     { t.propagations++ }
+func (t *countingTracer) Conflict(learnt []int, level int) { t.conflicts++ }
+func (t *countingTracer) Restart()                         { t.restarts++ }
+func (t *countingTracer) ReduceDB(removed int, kept int)   { t.reduceDBs++ }
+func (t *countingTracer) Model(model []int)                { t.models = append(t.models, model) }
+
+func TestTracer(t *testing.T) {
+	conf := config.New()
+	conf.Logger = log.New(io.Discard, "", 0)
+	s := New(conf)
+	tracer := &countingTracer{}
+
+	for _, c := range random3SAT(3, 150, 4.0) {
+		s.AddClause(c)
+	}
+	s.SetTracer(tracer)
+
+	if !s.Solve([]int{}) {
+		t.Fatalf("TestTracer() failed: unsat")
+	}
+	if tracer.decisions != s.NDecisions() || tracer.conflicts != s.NConflicts() {
+		t.Fatalf("TestTracer() failed, got decisions: %d, conflicts: %d",
+			tracer.decisions, tracer.conflicts)
+	}
+	if tracer.restarts != s.NRestarts()-1 || tracer.propagations == 0 {
+		t.Fatalf("TestTracer() failed, got restarts: %d, propagations: %d",
+			tracer.restarts, tracer.propagations)
+	}
+	if len(tracer.models) != 1 || fmt.Sprint(tracer.models[0]) != fmt.Sprint(s.Answer()) {
+		t.Fatalf("TestTracer() failed, got models: %v", tracer.models)
+	}
+}
+
+func TestTextTracer(t *testing.T) {
+	conf := config.New()
+	s := New(conf)
+	buf := bytes.Buffer{}
+
+	s.AddClause([]int{1, 2})
+	s.AddClause([]int{-1, 2})
+	s.SetTracer(NewTextTracer(&buf))
+	s.Solve([]int{})
+
+	if want := "decide 1 @1\npropagate 2 @1\nmodel [1 2]\n"; buf.String() != want {
+		t.Fatalf("TestTextTracer() failed, got: %q", buf.String())
+	}
+}
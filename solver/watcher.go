@@ -0,0 +1,61 @@
+package solver
+
+import "github.com/ericr/saturday/lit"
+
+// watcher is an entry in a literal's watch list.
+type watcher struct {
+	// clause is the watching clause.
+	clause cref
+	// blocker is a literal of the clause. When it's true the clause is
+	// satisfied and doesn't need to be visited.
+	blocker lit.Lit
+	// binary is true if the clause is binary, in which case blocker is the
+	// clause's other literal and the clause is never visited.
+	binary bool
+}
+
+// antecedent is the cause of an assignment. Binary clauses are propagated
+// implicitly by their watchers, so they're referred to by their other, false,
+// literal instead of the clause.
+type antecedent struct {
+	clause cref
+	other  lit.Lit
+}
+
+// noReason is the antecedent of decisions.
+var noReason = antecedent{clause: crefUndef, other: lit.Undef}
+
+// clauseReason returns the antecedent for an assignment implied by c.
+func clauseReason(c cref) antecedent {
+	return antecedent{clause: c, other: lit.Undef}
+}
+
+// externalReason is the antecedent of assignments propagated by an external
+// propagator, until their reason clause is needed.
+var externalReason = antecedent{clause: crefExternal, other: lit.Undef}
+
+// binaryReason returns the antecedent for an assignment implied by a binary
+// clause whose other literal, q, is false.
+func binaryReason(q lit.Lit) antecedent {
+	return antecedent{clause: crefUndef, other: q}
+}
+
+// external returns true if the assignment was propagated by an external
+// propagator and its reason clause hasn't been asked for yet.
+func (a antecedent) external() bool {
+	return a.clause == crefExternal
+}
+
+// decision returns true if the assignment wasn't implied.
+func (a antecedent) decision() bool {
+	return a.clause == crefUndef && a.other == lit.Undef
+}
+
+// reasonLits returns the false literals of the antecedent, i.e. all of the
+// clause's literals except the implied one.
+func (s *Solver) reasonLits(a antecedent) []lit.Lit {
+	if a.clause != crefUndef {
+		return s.ca.lits(a.clause)[1:]
+	}
+	return []lit.Lit{a.other}
+}